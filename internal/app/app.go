@@ -14,6 +14,7 @@ import (
 	"immich-photo-frame/internal/app/controller/planners"
 	"immich-photo-frame/internal/app/display"
 	"immich-photo-frame/internal/app/formatters"
+	"immich-photo-frame/internal/app/server"
 	"immich-photo-frame/internal/immich"
 )
 
@@ -27,12 +28,15 @@ type Config struct {
 	App struct {
 		ControllerConfig
 		DisplayConfig
-		ImmichAlbumRefreshInterval time.Duration
 	}
+	Server   ServerConfig
+	Schedule []ScheduleConfig
 }
 
 type DisplayConfig = display.Config
 type ControllerConfig = controller.Config
+type ServerConfig = server.Config
+type ScheduleConfig = controller.Schedule
 
 type photoFrame struct {
 	conf   Config
@@ -47,14 +51,29 @@ func (pf *photoFrame) run() error {
 	}
 
 	disp.SetKeyBinds(func(ke *fyne.KeyEvent) {
+		var err error
 		switch ke.Name {
 		case fyne.KeyRight:
-			ctrl.Next()
+			err = ctrl.Next()
 		case fyne.KeyLeft:
-			ctrl.Prev()
+			err = ctrl.Prev()
+		case fyne.KeySpace:
+			err = ctrl.TogglePause()
+		}
+		if err != nil {
+			slog.Warn("failed to handle key", "key", ke.Name, "error", err)
 		}
 	})
 
+	if pf.conf.Server.UseServer {
+		srv := server.New(pf.conf.Server, ctrl)
+		go func() {
+			if err := srv.ListenAndServe(); err != nil {
+				slog.Error("control server failed", "error", err)
+			}
+		}()
+	}
+
 	go ctrl.Run()
 
 	disp.ShowAndRun()
@@ -94,12 +113,28 @@ func LoadConfig() (*Config, error) {
 	conf.App.ImageDelay = 5 * time.Second
 	conf.App.ImageScale = 1
 	conf.App.HistorySize = 10
-	conf.App.PlanAlgorithm.PlanIter = new(planners.Sequential)
+	conf.App.PlanAlgorithm.PlanIter = planners.NewPipeline(new(planners.Sequential))
+	conf.App.MemoriesFallback.PlanIter = new(planners.Shuffle)
+	conf.App.RecentBiasHalfLife = 365 * 24 * time.Hour
+	conf.App.RecentBiasRepeatWindow = 50
+	conf.App.ChronologicalOrder = planners.SortOrderAsc
+	conf.App.EventGap = 12 * time.Hour
+	conf.App.EventDistance = 50
+	conf.App.EventSize = 10
+	conf.App.AssetQuality = immich.AssetQualityAuto
+	conf.App.MaxVideoLength = 30 * time.Second
 	conf.App.ImmichAlbumRefreshInterval = 24 * time.Hour
+	conf.App.Transition = display.TransitionNone
+	conf.App.TransitionDuration = 500 * time.Millisecond
+	conf.App.BackgroundFill = display.BackgroundFillBlack
 	conf.App.ImageText = []formatters.FormatConfig{
 		{TextSizeFormatter: formatters.NewSizeWrapper(new(formatters.ImageLocation), 16)},
 		{TextSizeFormatter: formatters.NewSizeWrapper(new(formatters.ImageDateTime), 20)},
 	}
+	conf.Server.ListenAddress = "127.0.0.1:8080"
+	conf.Remote.RequestTimeout = time.Minute
+	conf.Remote.MaxRetries = 2
+	conf.Remote.RetryDelay = 500 * time.Millisecond
 
 	// TOML-decode config file contents.
 	if _, err := toml.DecodeFile(configFilePath, &conf); err != nil {
@@ -109,6 +144,7 @@ func LoadConfig() (*Config, error) {
 	// Load values from environment variables.
 	conf.Remote.HydrateFromEnv()
 	conf.LocalStorage.LocalStoragePath = os.ExpandEnv(conf.LocalStorage.LocalStoragePath)
+	conf.App.Schedules = conf.Schedule
 
 	// Validate config values.
 	if err := conf.LocalStorage.Valid(); err != nil {
@@ -120,6 +156,13 @@ func LoadConfig() (*Config, error) {
 		)
 		conf.App.ImageScale = 1
 	}
+	if conf.App.TransitionDuration < 0 || conf.App.TransitionDuration > conf.App.ImageDelay {
+		slog.Warn("invalid transitionDuration value, resetting to default",
+			"error", "transitionDuration must be between 0 and imageDelay",
+		)
+		conf.App.TransitionDuration = min(500*time.Millisecond, conf.App.ImageDelay)
+	}
+	conf.App.SlideDuration = conf.App.ImageDelay
 	if conf.App.HistorySize < 0 {
 		slog.Warn("invalid historySize value, setting to 0",
 			"error", "historySize must be at least 0",
@@ -2,7 +2,11 @@ package controller
 
 import (
 	"errors"
+	"fmt"
+	"image"
 	"log/slog"
+	"slices"
+	"sync"
 	"time"
 
 	"immich-photo-frame/internal/app/controller/planners"
@@ -11,8 +15,11 @@ import (
 )
 
 var (
-	Next cmd = "next"
-	Prev cmd = "prev"
+	Next        cmd = "next"
+	Prev        cmd = "prev"
+	Pause       cmd = "pause"
+	Resume      cmd = "resume"
+	TogglePause cmd = "toggle-pause"
 )
 
 // cmd is an internal type representing a requested action performed by the
@@ -24,25 +31,85 @@ type cmd string
 // It is organized to take advantage of TOML parsing, however this package does
 // not handle parsing and has no expectation on how it will be initialized.
 type Config struct {
-	ImmichAlbums  []string
-	ImageDelay    time.Duration
-	HistorySize   int
-	PlanAlgorithm planners.PlanAlgorithm
+	ImmichAlbums               []string
+	ImageDelay                 time.Duration
+	HistorySize                int
+	PlanAlgorithm              planners.PlanAlgorithm
+	ImmichAlbumRefreshInterval time.Duration
+	PairPortraits              bool
+	AssetQuality               immich.AssetQuality
+	// PlayVideos plays video assets for their duration, up to
+	// MaxVideoLength, instead of showing their still image for ImageDelay.
+	// Plan algorithms without a type filter include videos when it is set.
+	PlayVideos     bool
+	MaxVideoLength time.Duration
+	// PlayLivePhotos plays the motion of Live Photos once when they are
+	// shown.
+	PlayLivePhotos bool
+	planners.Options
+	// Schedules replace ImmichAlbums and PlanAlgorithm while they are
+	// active. The first active Schedule is used.
+	Schedules []Schedule `toml:"-"`
+}
+
+// pairLookahead is how many assets ahead in the plan to look for a second
+// portrait image when pairing portraits.
+const pairLookahead = 5
+
+// planStateSaveInterval is the minimum amount of time between saving the
+// planner state, since it changes with every asset.
+const planStateSaveInterval = time.Minute
+
+// plan is a PlanAlgorithm and the albums it advances through, either from the
+// Config or from one of its Schedules.
+type plan struct {
+	// index is 0 for the Config and i+1 for Schedules[i].
+	index       int
+	name        string
+	algorithm   planners.PlanAlgorithm
+	albumNames  []string
+	initialized bool
+}
+
+// assetDisplay describes the display.Display methods used by the Controller.
+type assetDisplay interface {
+	Show(da display.DecodedAsset)
+	SetPaused(paused bool)
+	DecodeAsset(ass *immich.Asset) (*display.DecodedAsset, error)
+	Pair(left, right display.DecodedAsset) *display.DecodedAsset
+	TitleCard(md immich.AssetMetadata) *display.DecodedAsset
+	IsLandscape() bool
+	RenditionSize(md immich.AssetMetadata) int
 }
 
 // Controller gathers assets and drives the Display.
 type Controller struct {
-	conf             Config
+	conf Config
+	// plans holds the plan from the Config followed by one for each
+	// Schedule.
+	plans []*plan
+	// planMu guards the active plan, configuredAlbums, and the plans'
+	// PlanAlgorithms, which are used by the buffering worker and updated by
+	// the album refresher and scheduler.
+	planMu           sync.Mutex
+	active           *plan
 	configuredAlbums []immich.Album
-	// TODO: Change display.Display to an interface.
-	disp *display.Display
+	planStateSaved   time.Time
+	disp             assetDisplay
 	// TODO: Change immich.Client to an interface.
 	client *immich.Client
 	cmd    chan cmd
 	// TODO: Should we only store asset metadata since we can get the DecodedAsset from that?
 	bufferedAssets <-chan *display.DecodedAsset
-	history        []display.DecodedAsset
-	historyIndex   int
+	// pending holds asset metadata that was skipped while looking ahead for
+	// a portrait pair. It is only used by the buffering worker.
+	pending []immich.AssetMetadata
+	// stateMu guards history, historyIndex, and paused, which are modified
+	// by Run and read by the status methods.
+	stateMu      sync.RWMutex
+	history      []display.DecodedAsset
+	historyIndex int
+	paused       bool
 }
 
 // New initializes the Controller. An error is returned if it could not find
@@ -52,27 +119,34 @@ func New(conf Config, client *immich.Client, disp *display.Display) (*Controller
 	if err != nil {
 		return nil, err
 	}
-	albums := getConfiguredAlbums(allAlbums, conf.ImmichAlbums)
-	if n := countAssets(albums); n == 0 {
-		return nil, errors.New("no assets found")
+	plans, err := newPlans(conf)
+	if err != nil {
+		return nil, err
 	}
 	bufferedAssets := make(chan *display.DecodedAsset, 1)
 	ctrl := &Controller{
-		conf:             conf,
-		configuredAlbums: albums,
-		disp:             disp,
-		client:           client,
-		cmd:              make(chan cmd, 10),
-		bufferedAssets:   bufferedAssets,
-		history:          make([]display.DecodedAsset, conf.HistorySize+1),
-		historyIndex:     conf.HistorySize,
+		conf:           conf,
+		plans:          plans,
+		disp:           disp,
+		client:         client,
+		cmd:            make(chan cmd, 10),
+		bufferedAssets: bufferedAssets,
+		history:        make([]display.DecodedAsset, conf.HistorySize+1),
+		historyIndex:   conf.HistorySize,
+	}
+	// Initialize planner before the worker starts using it. If the
+	// scheduled plan has no assets, fall back to the plan from the Config.
+	ok := ctrl.activatePlan(ctrl.scheduledPlan(time.Now()), allAlbums) ||
+		ctrl.activatePlan(plans[0], allAlbums)
+	if !ok {
+		return nil, errors.New("no assets found")
 	}
 	// A controller is meant to run forever and currently does not support
 	// any sort of clean up or graceful shutdown, so for now we can just
 	// spawn this worker here without tracking its life.
 	go func() {
 		for {
-			ass, err := ctrl.nextAssetFromPlan()
+			ass, err := ctrl.nextSlide()
 			if err != nil {
 				slog.Error("failed to buffer next asset", "error", err)
 				continue
@@ -83,42 +157,162 @@ func New(conf Config, client *immich.Client, disp *display.Display) (*Controller
 	return ctrl, nil
 }
 
+// ErrBusy is returned when a command is requested while too many earlier
+// commands are still waiting to be processed, such as while the next asset is
+// downloading.
+var ErrBusy = errors.New("too many commands waiting, try again later")
+
 // Next requests that the next asset be shown immediately.
-func (c *Controller) Next() {
-	c.cmd <- Next
+func (c *Controller) Next() error {
+	return c.send(Next)
 }
 
 // Prev requests that the previous asset be shown immediately.
-func (c *Controller) Prev() {
-	c.cmd <- Prev
+func (c *Controller) Prev() error {
+	return c.send(Prev)
+}
+
+// Pause requests that the slideshow stop advancing automatically.
+func (c *Controller) Pause() error {
+	return c.send(Pause)
+}
+
+// Resume requests that the slideshow start advancing automatically again.
+func (c *Controller) Resume() error {
+	return c.send(Resume)
+}
+
+// TogglePause requests that the slideshow be paused if it is running, or
+// resumed if it is paused.
+func (c *Controller) TogglePause() error {
+	return c.send(TogglePause)
+}
+
+// send is a helper method to queue the command for Run without blocking. If
+// the queue is full, the command is dropped and ErrBusy is returned.
+func (c *Controller) send(cmd cmd) error {
+	select {
+	case c.cmd <- cmd:
+		return nil
+	default:
+		return ErrBusy
+	}
+}
+
+// Paused reports whether the slideshow is paused.
+func (c *Controller) Paused() bool {
+	c.stateMu.RLock()
+	defer c.stateMu.RUnlock()
+	return c.paused
+}
+
+// Current returns the metadata of the asset currently being displayed, if
+// any, and of the portrait image it is paired with, if it is paired.
+func (c *Controller) Current() (md immich.AssetMetadata, pair *immich.AssetMetadata, ok bool) {
+	c.stateMu.RLock()
+	defer c.stateMu.RUnlock()
+	ass := c.currentAsset()
+	return ass.Meta, ass.Pair, ass.Img != nil
+}
+
+// History returns the metadata of the assets in history, oldest first, along
+// with the index of the asset currently being displayed. Paired portraits are
+// both included, and the index refers to the first of the pair.
+func (c *Controller) History() ([]immich.AssetMetadata, int) {
+	c.stateMu.RLock()
+	defer c.stateMu.RUnlock()
+	var mds []immich.AssetMetadata
+	index := 0
+	for i, ass := range c.history {
+		if i == c.historyIndex {
+			index = len(mds)
+		}
+		if ass.Img == nil {
+			// Skip unfilled history entries.
+			continue
+		}
+		mds = append(mds, ass.Meta)
+		if ass.Pair != nil {
+			mds = append(mds, *ass.Pair)
+		}
+	}
+	return mds, index
+}
+
+// PlannerStatus reports the progress of the active PlanAlgorithm and the name
+// of the Schedule it is from, if any.
+func (c *Controller) PlannerStatus() planners.Status {
+	c.planMu.Lock()
+	defer c.planMu.Unlock()
+	status := planners.Status{Name: c.active.algorithm.Name()}
+	if planIter, ok := c.active.algorithm.PlanIter.(planners.StatusPlanIter); ok {
+		status = planIter.Status()
+	}
+	status.Schedule = c.active.name
+	return status
 }
 
 // Run drives the Display indefinitely.
 func (c *Controller) Run() {
-	// Initialize planner.
-	c.conf.PlanAlgorithm.Init(c.client, c.configuredAlbums)
+	go c.refreshAlbumsForever()
+	go c.scheduleForever()
 	// Initialize display by getting the first asset and showing it.
 	c.nextHistory()
 	c.disp.Show(c.currentAsset())
 
-	ticker := time.NewTicker(c.conf.ImageDelay)
+	ticker := time.NewTicker(c.slideDelay(c.currentAsset()))
 	for {
 		select {
 		case <-ticker.C:
 			c.nextHistory()
 		case cmd := <-c.cmd:
-			ticker.Reset(c.conf.ImageDelay)
 			switch cmd {
 			case Next:
 				c.nextHistory()
 			case Prev:
 				c.prevHistory()
+			case Pause, Resume, TogglePause:
+				paused := cmd == Pause || (cmd == TogglePause && !c.Paused())
+				c.setPaused(paused)
+				// Halt the ticker while paused so we don't pull more
+				// assets from the buffering worker.
+				if paused {
+					ticker.Stop()
+				} else {
+					ticker.Reset(c.slideDelay(c.currentAsset()))
+				}
+				continue
 			}
 		}
-		if ass := c.currentAsset(); ass.Img != nil {
+		ass := c.currentAsset()
+		if ass.Img != nil {
 			c.disp.Show(ass)
 		}
+		if !c.Paused() {
+			ticker.Reset(c.slideDelay(ass))
+		}
+	}
+}
+
+// slideDelay is a helper method to get how long to show the asset before
+// advancing. Videos are shown for as long as they play.
+func (c *Controller) slideDelay(ass display.DecodedAsset) time.Duration {
+	if ass.VideoPath != "" && ass.Duration > 0 {
+		return ass.Duration
 	}
+	return c.conf.ImageDelay
+}
+
+// setPaused is a helper method to update the paused state and the Display's
+// paused indicator.
+func (c *Controller) setPaused(paused bool) {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	if c.paused != paused {
+		slog.Info("slideshow paused state changed", "paused", paused)
+	}
+	c.paused = paused
+	c.disp.SetPaused(paused)
 }
 
 // currentAsset is a helper method to get the current asset to be displayed.
@@ -129,54 +323,479 @@ func (c *Controller) currentAsset() display.DecodedAsset {
 // nextHistory is a helper method to modify history or historyIndex to advance
 // the display.
 func (c *Controller) nextHistory() {
+	c.stateMu.Lock()
 	if c.historyIndex < len(c.history)-1 {
 		c.historyIndex++
+		c.stateMu.Unlock()
 		return
 	}
+	c.stateMu.Unlock()
+
+	// Wait for the next asset without holding the lock.
 	da := <-c.bufferedAssets
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
 	c.history = append(c.history, *da)
 	c.history = c.history[1:]
 }
 
 // prevHistory is a helper method to move historyIndex back one, if possible.
 func (c *Controller) prevHistory() {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
 	if c.historyIndex > 0 && c.history[c.historyIndex-1].Img != nil {
 		c.historyIndex--
 	}
 }
 
+// refreshAlbumsForever periodically calls [refreshAlbums] so new albums and
+// assets are picked up without restarting. It waits a full refresh interval
+// after each refresh completes, so the immich.Client considers its previous
+// responses stale by the time it is asked again. A refresh interval of 0
+// disables refreshing.
+func (c *Controller) refreshAlbumsForever() {
+	if c.conf.ImmichAlbumRefreshInterval <= 0 {
+		return
+	}
+	for {
+		time.Sleep(c.conf.ImmichAlbumRefreshInterval)
+		c.refreshAlbums()
+	}
+}
+
+// refreshAlbums re-resolves the active plan's albums and hands them to the
+// planner. Planners that implement planners.UpdatablePlanIter keep their
+// position, others are re-initialized. The album assets are fetched before
+// taking planMu, and history is not affected, so the slideshow continues
+// uninterrupted.
+func (c *Controller) refreshAlbums() {
+	allAlbums, err := c.client.GetAlbums()
+	if err != nil {
+		slog.Error("failed to refresh albums", "error", err)
+		return
+	}
+
+	c.planMu.Lock()
+	active := c.active
+	c.planMu.Unlock()
+	albums := getConfiguredAlbums(allAlbums, active.albumNames)
+	if n := countAssets(albums); n == 0 {
+		slog.Warn("no assets found after refreshing albums, keeping previous albums")
+		return
+	}
+	source := c.prefetchAlbumAssets(active.algorithm, albums)
+
+	c.planMu.Lock()
+	defer c.planMu.Unlock()
+	defer source.release()
+	if c.active != active {
+		// The schedule changed while fetching, which updated the new plan.
+		slog.Info("schedule changed while refreshing albums, skipping refresh")
+		return
+	}
+	c.configuredAlbums = albums
+	c.updatePlanner(source, albums)
+	c.savePlanState(true)
+	slog.Info("refreshed albums", "album_count", len(albums), "asset_count", countAssets(albums))
+}
+
+// scheduleForever checks which plan is scheduled at the start of every
+// minute and switches to it when it changes. It does nothing if there are no
+// Schedules.
+func (c *Controller) scheduleForever() {
+	if len(c.conf.Schedules) == 0 {
+		return
+	}
+	for {
+		now := time.Now()
+		time.Sleep(now.Truncate(time.Minute).Add(time.Minute).Sub(now))
+		c.applySchedule(time.Now())
+	}
+}
+
+// applySchedule switches to the plan scheduled at t if it is not already
+// active. The previous plan's planner keeps its position for the next time it
+// is scheduled. History is not affected, so the slideshow continues
+// uninterrupted, starting with the new plan after the buffered assets.
+func (c *Controller) applySchedule(t time.Time) {
+	p := c.scheduledPlan(t)
+	c.planMu.Lock()
+	active := c.active
+	c.planMu.Unlock()
+	if p == active {
+		return
+	}
+
+	allAlbums, err := c.client.GetAlbums()
+	if err != nil {
+		slog.Error("failed to get albums for schedule", "schedule", p.name, "error", err)
+		return
+	}
+	if !c.activatePlan(p, allAlbums) {
+		slog.Warn("no assets found for schedule, keeping previous schedule", "schedule", p.name)
+		return
+	}
+	c.planMu.Lock()
+	defer c.planMu.Unlock()
+	slog.Info("switched schedule",
+		"schedule", p.name,
+		"previous_schedule", active.name,
+		"plan_algorithm", p.algorithm.Name(),
+		"asset_count", countAssets(c.configuredAlbums),
+	)
+}
+
+// scheduledPlan is a helper method to get the plan of the first Schedule
+// active at t, or the plan from the Config if there is none.
+func (c *Controller) scheduledPlan(t time.Time) *plan {
+	for i, schedule := range c.conf.Schedules {
+		if schedule.Active(t) {
+			return c.plans[i+1]
+		}
+	}
+	return c.plans[0]
+}
+
+// activatePlan is a helper method to make p the active plan with its albums
+// from allAlbums. Its planner is initialized the first time it is activated
+// and updated after that. It returns false, leaving the active plan
+// unchanged, if p's albums have no assets. The album assets are fetched
+// before taking c.planMu, so it must not be held.
+func (c *Controller) activatePlan(p *plan, allAlbums []immich.Album) bool {
+	albums := getConfiguredAlbums(allAlbums, p.albumNames)
+	if n := countAssets(albums); n == 0 {
+		return false
+	}
+	source := c.prefetchAlbumAssets(p.algorithm, albums)
+
+	c.planMu.Lock()
+	defer c.planMu.Unlock()
+	defer source.release()
+	if c.active != nil && c.active != p {
+		c.savePlanState(true)
+	}
+	c.active = p
+	c.configuredAlbums = albums
+	if p.initialized {
+		c.updatePlanner(source, albums)
+	} else {
+		c.initPlanner(source, albums)
+		p.initialized = true
+	}
+	return true
+}
+
+// newPlans is a helper function to create the plan from the Config followed
+// by one for each Schedule. Schedules without a PlanAlgorithm get their own
+// instance of the Config's PlanAlgorithm, without its filters. If videos are
+// played, plans without a type filter include them.
+func newPlans(conf Config) ([]*plan, error) {
+	plans := []*plan{{algorithm: conf.PlanAlgorithm, albumNames: conf.ImmichAlbums}}
+	for i, schedule := range conf.Schedules {
+		name := schedule.Name
+		if name == "" {
+			name = fmt.Sprintf("schedule-%d", i+1)
+		}
+		algorithm := schedule.PlanAlgorithm
+		if algorithm.PlanIter == nil {
+			if err := algorithm.UnmarshalText([]byte(conf.PlanAlgorithm.Name())); err != nil {
+				return nil, fmt.Errorf("schedule %q: %w", name, err)
+			}
+		}
+		plans = append(plans, &plan{
+			index:      i + 1,
+			name:       name,
+			algorithm:  algorithm,
+			albumNames: schedule.ImmichAlbums,
+		})
+	}
+	if conf.PlayVideos {
+		for _, p := range plans {
+			if pipeline, ok := p.algorithm.PlanIter.(*planners.Pipeline); ok {
+				pipeline.IncludeVideos()
+			}
+		}
+	}
+	return plans, nil
+}
+
+// nextSlide is a helper method to get the next DecodedAsset to display. If
+// configured, a portrait image on a landscape display is paired with the next
+// portrait image found within [pairLookahead] assets of the plan. Assets that
+// are skipped over while looking are shown afterwards. Paired Live Photos are
+// shown without their motion.
+func (c *Controller) nextSlide() (*display.DecodedAsset, error) {
+	da, err := c.nextAssetFromPlan()
+	if err != nil || !c.conf.PairPortraits || da.Meta.Type != "IMAGE" || !isPortrait(da.Img.Bounds()) || !c.disp.IsLandscape() {
+		return da, err
+	}
+	for range pairLookahead {
+		md := c.nextMetadataFromPlan()
+		if md == nil {
+			break
+		}
+		if md.Type == planners.TitleCardType {
+			// Don't pair across the start of an event.
+			c.pending = append(c.pending, *md)
+			break
+		}
+		// Avoid downloading assets that are known to be landscape. Assets
+		// without dimensions are decoded to find out.
+		if w, h := md.ExifInfo.Dimensions(); md.Type != "IMAGE" || (h > 0 && h <= w) {
+			c.pending = append(c.pending, *md)
+			continue
+		}
+		pair, err := c.decodeAsset(*md)
+		if err != nil {
+			continue
+		} else if !isPortrait(pair.Img.Bounds()) {
+			// The metadata was wrong, so show it on its own later.
+			c.pending = append(c.pending, *md)
+			continue
+		}
+		slog.Debug("pairing portrait images", "id", da.Meta.ID, "pair_id", pair.Meta.ID)
+		return c.disp.Pair(*da, *pair), nil
+	}
+	return da, nil
+}
+
 // nextAssetFromPlan is a helper method to get the next immich asset from the
-// configured plan, download it, and decode it into a DecodedAsset. It retries
-// up to 5 times to get an asset and returns an error if it could not.
+// pending assets or configured plan, download it, and decode it into a
+// DecodedAsset. It retries up to 5 times to get an asset and returns an error
+// if it could not.
 func (c *Controller) nextAssetFromPlan() (*display.DecodedAsset, error) {
 	for range 5 {
-		md := c.conf.PlanAlgorithm.Next()
+		var md *immich.AssetMetadata
+		if len(c.pending) > 0 {
+			md, c.pending = &c.pending[0], c.pending[1:]
+		} else {
+			md = c.nextMetadataFromPlan()
+		}
 		if md == nil {
 			slog.Error("failed to get next asset metadata from planner")
 			continue
 		}
-		log := slog.With("id", md.ID, "name", md.Name)
-		if md.Type != "IMAGE" {
-			// Skip non-image assets even though retrieving the preview of it
-			// is still an image that can be displayed.
-			log.Debug("skipping unsupported non-image asset", "type", md.Type)
-			continue
+		if md.Type == planners.TitleCardType {
+			return c.disp.TitleCard(*md), nil
 		}
-		ass, err := c.client.GetAsset(*md)
+		da, err := c.decodeAsset(*md)
 		if err != nil {
-			log.Error("failed to get asset")
 			continue
 		}
-		da, err := c.disp.DecodeAsset(ass)
-		if err != nil {
-			log.Error("failed to decode asset")
-			continue
+		switch {
+		case md.Type == "VIDEO" && c.conf.PlayVideos:
+			c.addVideo(da)
+		case md.LivePhotoVideoID != "" && c.conf.PlayLivePhotos:
+			c.addLivePhoto(da)
 		}
 		return da, nil
 	}
 	return nil, errors.New("could not get the next asset after 5 tries")
 }
 
+// addVideo is a helper method to download the video of a video asset to local
+// storage so it is played from there over its still image, for up to
+// [Config.MaxVideoLength]. The still image is shown on its own if the video
+// could not be downloaded.
+func (c *Controller) addVideo(da *display.DecodedAsset) {
+	path, err := c.client.GetVideoPath(da.Meta.ID)
+	if err != nil {
+		slog.Warn("failed to get video, showing still image", "id", da.Meta.ID, "error", err)
+		return
+	}
+	da.VideoPath = path
+	da.Duration = c.conf.MaxVideoLength
+	if d, ok := da.Meta.VideoDuration(); ok && (d < da.Duration || da.Duration <= 0) {
+		da.Duration = d
+	}
+}
+
+// nextMetadataFromPlan is a helper method to get the next asset metadata from
+// the active plan.
+func (c *Controller) nextMetadataFromPlan() *immich.AssetMetadata {
+	c.planMu.Lock()
+	var next func() *immich.AssetMetadata
+	if planIter, ok := c.active.algorithm.PlanIter.(planners.DeferredPlanIter); ok {
+		next = planIter.NextDeferred()
+	} else {
+		md := c.active.algorithm.Next()
+		next = func() *immich.AssetMetadata { return md }
+	}
+	c.savePlanState(false)
+	c.planMu.Unlock()
+	// Look up the metadata without holding the lock, so a slow request
+	// does not hold up refreshing albums or reporting the status.
+	return next()
+}
+
+// initPlanner is a helper method to configure and initialize the active
+// planner. If the planner implements planners.StatefulPlanIter, it continues
+// from its saved state when possible. c.planMu must be held.
+func (c *Controller) initPlanner(source planners.AssetClient, albums []immich.Album) {
+	algorithm := c.active.algorithm
+	if planIter, ok := algorithm.PlanIter.(planners.ConfigurablePlanIter); ok {
+		planIter.Configure(c.conf.Options)
+	}
+	if planIter, ok := algorithm.PlanIter.(planners.StatefulPlanIter); ok {
+		state, err := c.client.GetState(planStateName(c.active))
+		if err == nil {
+			err = planIter.RestoreState(source, albums, state)
+		}
+		if err == nil {
+			slog.Info("restored planner state", "name", planIter.Name())
+			return
+		}
+		slog.Info("could not restore planner state, starting over", "name", planIter.Name(), "error", err)
+	}
+	algorithm.Init(source, albums)
+}
+
+// updatePlanner is a helper method to give the active planner new albums.
+// Planners that implement planners.UpdatablePlanIter keep their position,
+// others are re-initialized. c.planMu must be held.
+func (c *Controller) updatePlanner(source planners.AssetClient, albums []immich.Album) {
+	if planIter, ok := c.active.algorithm.PlanIter.(planners.UpdatablePlanIter); ok {
+		planIter.Update(source, albums)
+	} else {
+		c.active.algorithm.Init(source, albums)
+	}
+}
+
+// prefetchedClient is a planners.AssetClient that serves album assets fetched
+// ahead of time, so planners can be initialized or updated while holding
+// c.planMu without waiting on the immich server. Once released, and for
+// anything that was not prefetched, it uses the immich.Client, since planners
+// may keep their source to use later.
+type prefetchedClient struct {
+	*immich.Client
+	albumAssets map[immich.AlbumID][]immich.AssetMetadata
+}
+
+// prefetchAlbumAssets is a helper method to get the assets of the albums for a
+// prefetchedClient. If the algorithm implements planners.UncachedPlanIter,
+// they are fetched without the in-memory cache like it would. Albums that
+// could not be fetched are left for the planner to fetch, so it handles the
+// error as usual. c.planMu must not be held.
+func (c *Controller) prefetchAlbumAssets(algorithm planners.PlanAlgorithm, albums []immich.Album) *prefetchedClient {
+	getAlbumAssets := c.client.GetAlbumAssets
+	if planIter, ok := algorithm.PlanIter.(planners.UncachedPlanIter); ok && planIter.UncachedAlbumAssets() {
+		getAlbumAssets = c.client.GetAlbumAssetsUncached
+	}
+	p := &prefetchedClient{
+		Client:      c.client,
+		albumAssets: make(map[immich.AlbumID][]immich.AssetMetadata),
+	}
+	for _, album := range albums {
+		mds, err := getAlbumAssets(album.ID)
+		if err != nil {
+			continue
+		}
+		p.albumAssets[album.ID] = mds
+	}
+	return p
+}
+
+// GetAlbumAssets implements planners.AssetClient. Each call returns its own
+// copy, since planners may reorder the assets.
+func (p *prefetchedClient) GetAlbumAssets(id immich.AlbumID) ([]immich.AssetMetadata, error) {
+	if mds, ok := p.albumAssets[id]; ok {
+		return slices.Clone(mds), nil
+	}
+	return p.Client.GetAlbumAssets(id)
+}
+
+// GetAlbumAssetsUncached implements planners.UncachedAssetClient, see
+// [prefetchedClient.GetAlbumAssets].
+func (p *prefetchedClient) GetAlbumAssetsUncached(id immich.AlbumID) ([]immich.AssetMetadata, error) {
+	if mds, ok := p.albumAssets[id]; ok {
+		return slices.Clone(mds), nil
+	}
+	return p.Client.GetAlbumAssetsUncached(id)
+}
+
+// release drops the prefetched album assets so they are not kept in memory by
+// planners that keep their source. c.planMu must be held.
+func (p *prefetchedClient) release() {
+	p.albumAssets = nil
+}
+
+// savePlanState is a helper method to save the planner state, if it has any,
+// so it can be restored after a restart. Unless forced, it is saved at most
+// once per [planStateSaveInterval]. c.planMu must be held.
+func (c *Controller) savePlanState(force bool) {
+	planIter, ok := c.active.algorithm.PlanIter.(planners.StatefulPlanIter)
+	if !ok || (!force && time.Since(c.planStateSaved) < planStateSaveInterval) {
+		return
+	}
+	c.planStateSaved = time.Now()
+	state, err := planIter.SaveState()
+	if err == nil {
+		err = c.client.StoreState(planStateName(c.active), state)
+	}
+	if err != nil {
+		slog.Debug("failed to save planner state", "error", err)
+	}
+}
+
+// planStateName is a helper function to get the name the plan's planner state
+// is saved under. It includes the planner name so changing the configured
+// planner does not restore incompatible state, and the Schedule index so each
+// Schedule keeps its own state.
+func planStateName(p *plan) string {
+	if p.index == 0 {
+		return "plan-" + p.algorithm.Name()
+	}
+	return fmt.Sprintf("plan-schedule-%d-%s", p.index, p.algorithm.Name())
+}
+
+// decodeAsset is a helper method to download the asset and decode it into a
+// DecodedAsset.
+func (c *Controller) decodeAsset(md immich.AssetMetadata) (*display.DecodedAsset, error) {
+	log := slog.With("id", md.ID, "name", md.Name)
+	ass, err := c.client.GetAsset(md, c.assetQuality(md))
+	if err != nil {
+		log.Error("failed to get asset")
+		return nil, err
+	}
+	da, err := c.disp.DecodeAsset(ass)
+	if err != nil {
+		log.Error("failed to decode asset")
+		return nil, err
+	}
+	return da, nil
+}
+
+// addLivePhoto is a helper method to download the motion video of a Live Photo
+// to local storage so it is played once over the still image. The still image
+// is shown on its own if the video could not be downloaded.
+func (c *Controller) addLivePhoto(da *display.DecodedAsset) {
+	path, err := c.client.GetLivePhotoVideoPath(da.Meta)
+	if err != nil {
+		slog.Warn("failed to get live photo video, showing still image", "id", da.Meta.ID, "error", err)
+		return
+	}
+	da.VideoPath = path
+}
+
+// assetQuality is a helper method to get the quality to download the asset in.
+// The auto quality is the smallest immich rendition that covers the asset's
+// area of the screen, or the preview until the screen size is known.
+func (c *Controller) assetQuality(md immich.AssetMetadata) immich.AssetQuality {
+	if c.conf.AssetQuality != immich.AssetQualityAuto {
+		return c.conf.AssetQuality
+	}
+	if size := c.disp.RenditionSize(md); size > 0 {
+		return immich.SmallestAssetQuality(size)
+	}
+	return immich.AssetQualityPreview
+}
+
+// isPortrait is a helper function to check if an image is taller than it is
+// wide.
+func isPortrait(bounds image.Rectangle) bool {
+	return bounds.Dy() > bounds.Dx()
+}
+
 // getConfiguredAlbums is a helper function to convert a list of album names
 // into a list of immich Album objects. An error is returned iff there was a
 // problem getting all of the albums from the immich Client.
@@ -1,10 +1,236 @@
 package controller
 
 import (
-	"immich-photo-frame/internal/immich"
+	"encoding/json"
+	"errors"
+	"image"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"immich-photo-frame/internal/app/controller/planners"
+	"immich-photo-frame/internal/app/display"
+	"immich-photo-frame/internal/immich"
+	"immich-photo-frame/internal/immich/api"
 )
 
+// fakeImmich is a helper type to serve albums and their assets like the immich
+// API.
+type fakeImmich struct {
+	mu     sync.Mutex
+	albums []immich.Album
+	assets map[immich.AlbumID][]immich.AssetMetadata
+	// block, if set, holds album asset and asset metadata requests until
+	// it is closed.
+	// requested receives a value each time a request is held.
+	block     chan struct{}
+	requested chan struct{}
+}
+
+// newFakeImmich is a helper function to create a fakeImmich with a single
+// album of images with the provided IDs.
+func newFakeImmich(ids ...immich.AssetID) *fakeImmich {
+	f := &fakeImmich{
+		assets:    make(map[immich.AlbumID][]immich.AssetMetadata),
+		requested: make(chan struct{}, 10),
+	}
+	f.setAlbum("album-1", ids...)
+	return f
+}
+
+// setAlbum is a helper method to add or replace an album of images with the
+// provided IDs.
+func (f *fakeImmich) setAlbum(id immich.AlbumID, ids ...immich.AssetID) {
+	var mds []immich.AssetMetadata
+	for _, id := range ids {
+		mds = append(mds, immich.AssetMetadata{ID: id, Type: "IMAGE"})
+	}
+	f.setAlbumAssets(id, mds...)
+}
+
+// setAlbumAssets is a helper method to add or replace an album of the provided
+// assets.
+func (f *fakeImmich) setAlbumAssets(id immich.AlbumID, mds ...immich.AssetMetadata) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.assets[id] = mds
+	f.albums = slices.DeleteFunc(f.albums, func(a immich.Album) bool { return a.ID == id })
+	f.albums = append(f.albums, immich.Album{ID: id, Name: string(id), AssetCount: len(mds)})
+}
+
+// setBlock is a helper method to start or stop holding album asset requests.
+func (f *fakeImmich) setBlock(block chan struct{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.block = block
+}
+
+func (f *fakeImmich) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/api/albums" {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		json.NewEncoder(w).Encode(f.albums)
+		return
+	}
+	switch path.Base(r.URL.Path) {
+	case "thumbnail", "original":
+		// The fakeDisplay decodes assets from their metadata, so any
+		// data will do.
+		w.Write([]byte("asset"))
+		return
+	case "playback":
+		w.Write([]byte("video"))
+		return
+	}
+	f.mu.Lock()
+	block := f.block
+	f.mu.Unlock()
+	if block != nil {
+		f.requested <- struct{}{}
+		<-block
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if id, ok := strings.CutPrefix(r.URL.Path, "/api/assets/"); ok {
+		for _, mds := range f.assets {
+			if i := slices.IndexFunc(mds, func(md immich.AssetMetadata) bool { return md.ID == immich.AssetID(id) }); i >= 0 {
+				json.NewEncoder(w).Encode(mds[i])
+				return
+			}
+		}
+		http.NotFound(w, r)
+		return
+	}
+	mds, ok := f.assets[immich.AlbumID(strings.TrimPrefix(r.URL.Path, "/api/albums/"))]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]any{"assets": mds})
+}
+
+var _ assetDisplay = (*display.Display)(nil)
+
+// fakeDisplay is a helper type implementing assetDisplay without a GUI. Decoded
+// images have the dimensions in the asset's EXIF info, or are a single pixel.
+type fakeDisplay struct {
+	mu        sync.Mutex
+	landscape bool
+	paused    bool
+	shown     chan immich.AssetID
+}
+
+func newFakeDisplay() *fakeDisplay {
+	return &fakeDisplay{landscape: true, shown: make(chan immich.AssetID, 100)}
+}
+
+func (d *fakeDisplay) Show(da display.DecodedAsset) { d.shown <- da.Meta.ID }
+
+func (d *fakeDisplay) SetPaused(paused bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.paused = paused
+}
+
+// isPaused is a helper method to get the last value passed to SetPaused.
+func (d *fakeDisplay) isPaused() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.paused
+}
+
+func (d *fakeDisplay) DecodeAsset(ass *immich.Asset) (*display.DecodedAsset, error) {
+	w, h := ass.Meta.ExifInfo.Dimensions()
+	return &display.DecodedAsset{Meta: ass.Meta, Img: image.NewGray(image.Rect(0, 0, max(w, 1), max(h, 1)))}, nil
+}
+
+func (d *fakeDisplay) Pair(left, right display.DecodedAsset) *display.DecodedAsset {
+	return &display.DecodedAsset{Meta: left.Meta, Img: left.Img, Pair: &right.Meta}
+}
+
+func (d *fakeDisplay) TitleCard(md immich.AssetMetadata) *display.DecodedAsset {
+	return &display.DecodedAsset{Meta: md, Img: image.NewGray(image.Rect(0, 0, 1, 1)), Title: []string{md.Name}}
+}
+
+func (d *fakeDisplay) IsLandscape() bool                      { return d.landscape }
+func (d *fakeDisplay) RenditionSize(immich.AssetMetadata) int { return 0 }
+
+// assertShown is a helper function to check the next asset shown on the
+// fakeDisplay.
+func assertShown(t *testing.T, d *fakeDisplay, want immich.AssetID) {
+	t.Helper()
+	select {
+	case id := <-d.shown:
+		if id != want {
+			t.Fatalf("expected %q to be shown, got %q", want, id)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected %q to be shown", want)
+	}
+}
+
+// newTestClient is a helper function to create an immich.Client for the
+// fakeImmich, with local storage if it is configured.
+func newTestClient(t *testing.T, f *fakeImmich, local immich.LocalConfig) *immich.Client {
+	t.Helper()
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return immich.NewClient(
+		immich.WithLocalStorage(local),
+		immich.WithRemote(api.Config{ImmichAPIEndpoint: srv.URL}),
+	)
+}
+
+// newTestController is a helper function to create a Controller for the
+// fakeImmich without a Display or buffering worker, with the plan from the
+// Config active.
+func newTestController(t *testing.T, conf Config, f *fakeImmich) *Controller {
+	t.Helper()
+	client := newTestClient(t, f, immich.LocalConfig{})
+	if conf.PlanAlgorithm.PlanIter == nil {
+		conf.PlanAlgorithm.PlanIter = planners.NewPipeline(new(planners.Sequential))
+	}
+	plans, err := newPlans(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Controller{
+		conf:         conf,
+		plans:        plans,
+		client:       client,
+		disp:         newFakeDisplay(),
+		cmd:          make(chan cmd, 10),
+		history:      make([]display.DecodedAsset, conf.HistorySize+1),
+		historyIndex: conf.HistorySize,
+	}
+	allAlbums, err := client.GetAlbums()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.activatePlan(plans[0], allAlbums) {
+		t.Fatal("expected the plan to have assets")
+	}
+	return c
+}
+
+// assertNextIDs is a helper function to check the next asset IDs from the
+// Controller's plan.
+func assertNextIDs(t *testing.T, c *Controller, want ...immich.AssetID) {
+	t.Helper()
+	for _, id := range want {
+		md := c.nextMetadataFromPlan()
+		if md == nil || md.ID != id {
+			t.Fatalf("expected next asset %q, got %+v", id, md)
+		}
+	}
+}
+
 func Test_getConfiguredAlbums_Stable(t *testing.T) {
 	got := getConfiguredAlbums(
 		[]immich.Album{
@@ -39,3 +265,306 @@ func Test_getConfiguredAlbums_Duplicate(t *testing.T) {
 		t.Fatalf(`expected second element to be "album-1", found %q`, got[1].Name)
 	}
 }
+
+func TestRefreshAlbumsKeepsPositionAndHistory(t *testing.T) {
+	f := newFakeImmich("asset-1", "asset-2", "asset-3")
+	c := newTestController(t, Config{HistorySize: 2}, f)
+	assertNextIDs(t, c, "asset-1", "asset-2")
+	c.history = []display.DecodedAsset{
+		{},
+		{Meta: immich.AssetMetadata{ID: "asset-1"}},
+		{Meta: immich.AssetMetadata{ID: "asset-2"}},
+	}
+	history := slices.Clone(c.history)
+
+	f.setAlbum("album-1", "asset-1", "asset-2", "asset-3", "asset-4")
+	block := make(chan struct{})
+	f.setBlock(block)
+	refreshed := make(chan struct{})
+	go func() {
+		c.refreshAlbums()
+		close(refreshed)
+	}()
+	<-f.requested
+
+	// The slideshow and status must not wait on the refresh.
+	next := make(chan *immich.AssetMetadata)
+	go func() {
+		c.PlannerStatus()
+		next <- c.nextMetadataFromPlan()
+	}()
+	select {
+	case md := <-next:
+		if md == nil || md.ID != "asset-3" {
+			t.Fatalf("expected next asset %q, got %+v", "asset-3", md)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the planner to be usable while refreshing albums")
+	}
+	f.setBlock(nil)
+	close(block)
+	<-refreshed
+
+	assertNextIDs(t, c, "asset-4")
+	if !slices.EqualFunc(c.history, history, func(a, b display.DecodedAsset) bool { return a.Meta.ID == b.Meta.ID }) {
+		t.Errorf("expected history to be unchanged, got %v", c.history)
+	}
+	if c.historyIndex != 2 {
+		t.Errorf("expected history index 2, got %d", c.historyIndex)
+	}
+}
+
+func TestRefreshAlbumsKeepsPreviousAlbumsWithoutAssets(t *testing.T) {
+	f := newFakeImmich("asset-1", "asset-2")
+	c := newTestController(t, Config{ImmichAlbums: []string{"album-1"}}, f)
+	assertNextIDs(t, c, "asset-1")
+
+	f.setAlbum("album-1")
+	c.refreshAlbums()
+	assertNextIDs(t, c, "asset-2")
+}
+
+// onceThrough is a helper type implementing planners.PlanIter that returns the
+// assets of the albums in order, and then nil instead of starting over.
+type onceThrough struct {
+	assets []immich.AssetMetadata
+}
+
+func (o *onceThrough) Name() string { return "once-through" }
+
+func (o *onceThrough) Init(source planners.AssetClient, albums []immich.Album) {
+	o.assets = nil
+	for _, album := range albums {
+		assets, _ := source.GetAlbumAssets(album.ID)
+		o.assets = append(o.assets, assets...)
+	}
+}
+
+func (o *onceThrough) Next() *immich.AssetMetadata {
+	if len(o.assets) == 0 {
+		return nil
+	}
+	md := o.assets[0]
+	o.assets = o.assets[1:]
+	return &md
+}
+
+func TestNextSlide(t *testing.T) {
+	withSize := func(id immich.AssetID, w, h int) immich.AssetMetadata {
+		return immich.AssetMetadata{ID: id, Type: "IMAGE", ExifInfo: immich.ExifInfo{ExifImageWidth: w, ExifImageHeight: h}}
+	}
+	portrait := func(id immich.AssetID) immich.AssetMetadata { return withSize(id, 3, 4) }
+	landscape := func(id immich.AssetID) immich.AssetMetadata { return withSize(id, 4, 3) }
+
+	for _, tc := range []struct {
+		name       string
+		assets     []immich.AssetMetadata
+		portraitUI bool
+		// want is the IDs of each slide, with the paired asset second.
+		want [][]immich.AssetID
+	}{{
+		name: "pairs the next portrait and shows skipped assets after in order",
+		assets: []immich.AssetMetadata{
+			portrait("p-1"), landscape("l-1"), {ID: "v-1", Type: "VIDEO"}, portrait("p-2"), landscape("l-2"),
+		},
+		want: [][]immich.AssetID{{"p-1", "p-2"}, {"l-1"}, {"v-1"}, {"l-2"}},
+	}, {
+		name: "decodes assets without dimensions to check them",
+		assets: []immich.AssetMetadata{
+			portrait("p-1"), {ID: "u-1", Type: "IMAGE"}, portrait("p-2"),
+		},
+		want: [][]immich.AssetID{{"p-1", "p-2"}, {"u-1"}},
+	}, {
+		name: "does not pair across title cards",
+		assets: []immich.AssetMetadata{
+			portrait("p-1"), {ID: "t-1", Type: planners.TitleCardType}, portrait("p-2"), portrait("p-3"),
+		},
+		want: [][]immich.AssetID{{"p-1"}, {"t-1"}, {"p-2", "p-3"}},
+	}, {
+		name: "stops looking after the lookahead",
+		assets: []immich.AssetMetadata{
+			portrait("p-1"), landscape("l-1"), landscape("l-2"), landscape("l-3"), landscape("l-4"), landscape("l-5"), portrait("p-2"),
+		},
+		want: [][]immich.AssetID{{"p-1"}, {"l-1"}, {"l-2"}, {"l-3"}, {"l-4"}, {"l-5"}, {"p-2"}},
+	}, {
+		name:       "does not pair on a portrait display",
+		assets:     []immich.AssetMetadata{portrait("p-1"), portrait("p-2")},
+		portraitUI: true,
+		want:       [][]immich.AssetID{{"p-1"}, {"p-2"}},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newFakeImmich()
+			f.setAlbumAssets("album-1", tc.assets...)
+			c := newTestController(t, Config{
+				PairPortraits: true,
+				PlanAlgorithm: planners.PlanAlgorithm{PlanIter: new(onceThrough)},
+			}, f)
+			c.disp.(*fakeDisplay).landscape = !tc.portraitUI
+
+			for _, want := range tc.want {
+				da, err := c.nextSlide()
+				if err != nil {
+					t.Fatalf("expected slide %v, got error: %v", want, err)
+				}
+				got := []immich.AssetID{da.Meta.ID}
+				if da.Pair != nil {
+					got = append(got, da.Pair.ID)
+				}
+				if !slices.Equal(got, want) {
+					t.Fatalf("expected slide %v, got %v", want, got)
+				}
+			}
+			if len(c.pending) > 0 {
+				t.Errorf("expected no pending assets, got %v", c.pending)
+			}
+		})
+	}
+}
+
+func TestNextMetadataFromPlanDoesNotHoldLock(t *testing.T) {
+	f := newFakeImmich("asset-1")
+	c := newTestController(t, Config{
+		PlanAlgorithm: planners.PlanAlgorithm{PlanIter: planners.NewPipeline(new(planners.StreamingShuffle))},
+	}, f)
+
+	block := make(chan struct{})
+	f.setBlock(block)
+	next := make(chan *immich.AssetMetadata)
+	go func() { next <- c.nextMetadataFromPlan() }()
+	<-f.requested
+
+	// The status must not wait on the metadata request.
+	status := make(chan planners.Status)
+	go func() { status <- c.PlannerStatus() }()
+	select {
+	case st := <-status:
+		if st.Position != 1 {
+			t.Errorf("expected position 1, got %+v", st)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the planner to be usable while getting asset metadata")
+	}
+	f.setBlock(nil)
+	close(block)
+	if md := <-next; md == nil || md.ID != "asset-1" {
+		t.Fatalf("expected next asset %q, got %+v", "asset-1", md)
+	}
+}
+
+func TestPlayVideosIncludesVideos(t *testing.T) {
+	f := newFakeImmich()
+	f.setAlbumAssets("album-1", immich.AssetMetadata{ID: "image-1", Type: "IMAGE"}, immich.AssetMetadata{ID: "video-1", Type: "VIDEO"})
+
+	c := newTestController(t, Config{}, f)
+	assertNextIDs(t, c, "image-1", "image-1")
+	c = newTestController(t, Config{PlayVideos: true}, f)
+	assertNextIDs(t, c, "image-1", "video-1")
+}
+
+func TestNextAssetFromPlanAddsVideoPath(t *testing.T) {
+	f := newFakeImmich()
+	f.setAlbumAssets("album-1", immich.AssetMetadata{ID: "video-1", Type: "VIDEO", Duration: "0:00:02.000000"})
+	conf := Config{ImageDelay: time.Second, PlayVideos: true, MaxVideoLength: time.Minute}
+
+	// Without local storage, the still image is shown for the image delay.
+	c := newTestController(t, conf, f)
+	da, err := c.nextAssetFromPlan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if da.VideoPath != "" || c.slideDelay(*da) != time.Second {
+		t.Fatalf("expected the still image without local storage, got %q for %v", da.VideoPath, c.slideDelay(*da))
+	}
+
+	c.client = newTestClient(t, f, immich.LocalConfig{
+		UseLocalStorage:  true,
+		LocalStorageSize: 1000,
+		LocalStoragePath: t.TempDir(),
+	})
+	da, err = c.nextAssetFromPlan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(da.VideoPath)
+	if err != nil {
+		t.Fatalf("expected the video in local storage, got error: %v", err)
+	}
+	if string(data) != "video" {
+		t.Errorf("expected the video to be %q, got %q", "video", data)
+	}
+	if got := c.slideDelay(*da); got != 2*time.Second {
+		t.Errorf("expected the video to be shown for its duration, got %v", got)
+	}
+}
+
+func TestCommandsDoNotBlock(t *testing.T) {
+	c := &Controller{cmd: make(chan cmd, 2)}
+	for _, send := range []func() error{c.Next, c.Prev} {
+		if err := send(); err != nil {
+			t.Fatalf("expected the command to be queued, got %v", err)
+		}
+	}
+	if err := c.Pause(); !errors.Is(err, ErrBusy) {
+		t.Fatalf("expected %v with a full queue, got %v", ErrBusy, err)
+	}
+	if got := <-c.cmd; got != Next {
+		t.Errorf("expected %q to be queued first, got %q", Next, got)
+	}
+}
+
+func TestRunPauseAndResume(t *testing.T) {
+	c := newTestController(t, Config{ImageDelay: 20 * time.Millisecond}, newFakeImmich("asset-1"))
+	disp := c.disp.(*fakeDisplay)
+	buffered := make(chan *display.DecodedAsset)
+	c.bufferedAssets = buffered
+	go c.Run()
+
+	img := image.NewGray(image.Rect(0, 0, 1, 1))
+	buffered <- &display.DecodedAsset{Meta: immich.AssetMetadata{ID: "asset-1"}, Img: img}
+	assertShown(t, disp, "asset-1")
+	// A video is shown for its duration rather than the image delay.
+	video := &display.DecodedAsset{
+		Meta:      immich.AssetMetadata{ID: "video-1", Type: "VIDEO"},
+		Img:       img,
+		VideoPath: "video.mp4",
+		Duration:  500 * time.Millisecond,
+	}
+	buffered <- video
+	assertShown(t, disp, "video-1")
+
+	if err := c.Pause(); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "the display to be paused", disp.isPaused)
+	if !c.Paused() {
+		t.Error("expected the controller to be paused")
+	}
+	select {
+	case buffered <- &display.DecodedAsset{Meta: immich.AssetMetadata{ID: "asset-2"}, Img: img}:
+		t.Fatal("expected no assets to be pulled while paused")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	if err := c.TogglePause(); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	buffered <- &display.DecodedAsset{Meta: immich.AssetMetadata{ID: "asset-2"}, Img: img}
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Errorf("expected the video to be shown for its duration after resuming, advanced after %v", elapsed)
+	}
+	assertShown(t, disp, "asset-2")
+	if disp.isPaused() || c.Paused() {
+		t.Error("expected the slideshow to be resumed")
+	}
+}
+
+// waitFor is a helper function to wait up to a second for cond to be true.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); !cond(); time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package planners
+
+import (
+	"encoding/json"
+	"slices"
+	"time"
+
+	"immich-photo-frame/internal/immich"
+)
+
+// Chronological implements PlanIter by merging the assets of all configured
+// albums into a single timeline sorted by their EXIF date, in ascending order
+// unless configured otherwise. Assets in several albums are only shown once,
+// and assets without a date are shown at the end of the timeline.
+type Chronological struct {
+	order      SortOrder
+	assets     []datedAsset
+	assetIndex int
+}
+
+// datedAsset is an asset and when it was taken.
+type datedAsset struct {
+	md   immich.AssetMetadata
+	date time.Time
+}
+
+func (c *Chronological) Name() string { return "chronological" }
+
+// Configure implements ConfigurablePlanIter and sets the order.
+func (c *Chronological) Configure(opts Options) {
+	c.order = opts.ChronologicalOrder
+}
+
+// Init implements PlanIter and initializes the Chronological object.
+func (c *Chronological) Init(source AssetClient, albums []immich.Album) {
+	c.resume(source, albums, chronologicalState{})
+}
+
+// Update implements UpdatablePlanIter. It continues after the last returned
+// asset, or where that asset was in the timeline if it was removed.
+func (c *Chronological) Update(source AssetClient, albums []immich.Album) {
+	c.resume(source, albums, c.state())
+}
+
+// Next implements PlanIter and retrieves the next AssetMetadata. The timeline
+// starts over after the last asset.
+func (c *Chronological) Next() *immich.AssetMetadata {
+	if len(c.assets) == 0 {
+		return nil
+	}
+	if c.assetIndex >= len(c.assets) {
+		c.assetIndex = 0
+	}
+	md := c.assets[c.assetIndex].md
+	c.assetIndex++
+	return &md
+}
+
+// Status implements StatusPlanIter and reports the position in the timeline.
+func (c *Chronological) Status() Status {
+	return Status{
+		Name:     c.Name(),
+		Position: c.assetIndex,
+		Total:    len(c.assets),
+	}
+}
+
+// chronologicalState is the saved state of a Chronological.
+type chronologicalState struct {
+	AssetID immich.AssetID `json:"assetId"`
+	Date    time.Time      `json:"date"`
+}
+
+// SaveState implements StatefulPlanIter and saves the last returned asset.
+func (c *Chronological) SaveState() ([]byte, error) {
+	return json.Marshal(c.state())
+}
+
+// RestoreState implements StatefulPlanIter and continues after the saved
+// asset, the same as [Chronological.Update].
+func (c *Chronological) RestoreState(source AssetClient, albums []immich.Album, state []byte) error {
+	var st chronologicalState
+	if err := json.Unmarshal(state, &st); err != nil {
+		return err
+	}
+	c.resume(source, albums, st)
+	return nil
+}
+
+// state is a helper method to get the current chronologicalState.
+func (c *Chronological) state() chronologicalState {
+	if c.assetIndex <= 0 || c.assetIndex > len(c.assets) {
+		return chronologicalState{}
+	}
+	last := c.assets[c.assetIndex-1]
+	return chronologicalState{AssetID: last.md.ID, Date: last.date}
+}
+
+// resume is a helper method to rebuild the timeline from the albums' assets
+// and continue after the asset in st.
+func (c *Chronological) resume(source AssetClient, albums []immich.Album, st chronologicalState) {
+	seen := make(map[immich.AssetID]bool)
+	var assets []datedAsset
+	for _, md := range getAllAlbumAssets(source, albums) {
+		if seen[md.ID] {
+			continue
+		}
+		seen[md.ID] = true
+		date, _ := assetTime(md)
+		assets = append(assets, datedAsset{md: md, date: date})
+	}
+	slices.SortStableFunc(assets, c.compare)
+	*c = Chronological{order: c.order, assets: assets}
+
+	if st.AssetID == "" {
+		return
+	}
+	if i := slices.IndexFunc(assets, func(da datedAsset) bool { return da.md.ID == st.AssetID }); i >= 0 {
+		c.assetIndex = i + 1
+		return
+	}
+	// The asset was removed, so continue with the first asset after it.
+	last := datedAsset{date: st.Date}
+	c.assetIndex = len(assets)
+	if i := slices.IndexFunc(assets, func(da datedAsset) bool { return c.compare(last, da) < 0 }); i >= 0 {
+		c.assetIndex = i
+	}
+}
+
+// compare is a helper method to order assets by date in the configured order.
+// Assets without a date are always last.
+func (c *Chronological) compare(a, b datedAsset) int {
+	switch {
+	case a.date.IsZero() && b.date.IsZero():
+		return 0
+	case a.date.IsZero():
+		return 1
+	case b.date.IsZero():
+		return -1
+	}
+	if c.order == SortOrderDesc {
+		return b.date.Compare(a.date)
+	}
+	return a.date.Compare(b.date)
+}
@@ -0,0 +1,72 @@
+package planners_test
+
+import (
+	"testing"
+
+	"immich-photo-frame/internal/app/controller/planners"
+	"immich-photo-frame/internal/immich"
+)
+
+// testTimelineClient is a helper function to create an AssetClient with a trip
+// that spans two albums.
+func testTimelineClient() testAssetClient {
+	return testAssetClient{
+		lut: map[immich.AlbumID][]immich.AssetMetadata{
+			"album-1": {
+				{ID: "day-3", ExifInfo: immich.ExifInfo{DateTimeOriginal: "2024-07-03T09:00:00.000Z"}},
+				{ID: "day-1", ExifInfo: immich.ExifInfo{DateTimeOriginal: "2024-07-01T09:00:00.000Z"}},
+				{ID: "undated"},
+			},
+			"album-2": {
+				{ID: "day-2", ExifInfo: immich.ExifInfo{DateTimeOriginal: "2024-07-02T09:00:00.000+02:00"}},
+				{ID: "day-3", ExifInfo: immich.ExifInfo{DateTimeOriginal: "2024-07-03T09:00:00.000Z"}},
+			},
+		},
+	}
+}
+
+// TestChronological tests Chronological merges albums into one timeline in
+// the configured order, without duplicates.
+func TestChronological(t *testing.T) {
+	albums := []immich.Album{{ID: "album-1"}, {ID: "album-2"}}
+	for _, tt := range []struct {
+		order    planners.SortOrder
+		expected []immich.AssetID
+	}{
+		{"", []immich.AssetID{"day-1", "day-2", "day-3", "undated", "day-1"}},
+		{planners.SortOrderAsc, []immich.AssetID{"day-1", "day-2", "day-3", "undated", "day-1"}},
+		{planners.SortOrderDesc, []immich.AssetID{"day-3", "day-2", "day-1", "undated", "day-3"}},
+	} {
+		t.Run(string(tt.order), func(t *testing.T) {
+			var chrono planners.Chronological
+			chrono.Configure(planners.Options{ChronologicalOrder: tt.order})
+			chrono.Init(testTimelineClient(), albums)
+			assertIDs(t, tt.expected, nextIDs(&chrono, len(tt.expected)))
+		})
+	}
+}
+
+// TestChronologicalUpdate tests Chronological continues where the last
+// returned asset was in the timeline, even if it was removed.
+func TestChronologicalUpdate(t *testing.T) {
+	albums := []immich.Album{{ID: "album-1"}, {ID: "album-2"}}
+	client := testTimelineClient()
+	var chrono planners.Chronological
+	chrono.Init(client, albums)
+	nextIDs(&chrono, 2)
+
+	// Remove day-2, which was just shown.
+	client.lut["album-2"] = client.lut["album-2"][1:]
+	chrono.Update(client, albums)
+	assertIDs(t, []immich.AssetID{"day-3", "undated"}, nextIDs(&chrono, 2))
+}
+
+func TestSortOrderUnmarshalText(t *testing.T) {
+	var order planners.SortOrder
+	if err := order.UnmarshalText([]byte("DESC")); err != nil || order != planners.SortOrderDesc {
+		t.Fatalf("expected %q, got %q (error: %v)", planners.SortOrderDesc, order, err)
+	}
+	if err := order.UnmarshalText([]byte("newest")); err == nil {
+		t.Fatal("expected an error for an unsupported order")
+	}
+}
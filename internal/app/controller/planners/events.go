@@ -0,0 +1,194 @@
+package planners
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"time"
+
+	"immich-photo-frame/internal/app/formatters"
+	"immich-photo-frame/internal/immich"
+)
+
+// TitleCardType is the AssetMetadata.Type of the title cards returned by
+// [Events]. Title cards are not immich assets; their Name holds the lines of
+// text to show.
+const TitleCardType = "TITLE_CARD"
+
+// Defaults used by Events for options that are not configured.
+const (
+	defaultEventGap      = 12 * time.Hour
+	defaultEventDistance = 50
+	defaultEventSize     = 10
+)
+
+// Events implements PlanIter by grouping assets into events, such as a day
+// out or a trip, then showing the events in a random order. A new event
+// starts when there is a large enough gap in time or distance between assets,
+// by their EXIF date and GPS coordinates. Up to the configured number of each
+// event's assets are shown in chronological order, optionally after a title
+// card with the event's location and dates. Assets without a date are not
+// shown.
+type Events struct {
+	gap        time.Duration
+	distance   float64
+	size       int
+	titleCards bool
+
+	events     [][]immich.AssetMetadata
+	eventIndex int
+	// queue holds the assets left to show from the current event.
+	queue []immich.AssetMetadata
+}
+
+func (e *Events) Name() string { return "events" }
+
+// Configure implements ConfigurablePlanIter and sets how events are grouped
+// and shown.
+func (e *Events) Configure(opts Options) {
+	e.gap = positiveOr(opts.EventGap, defaultEventGap)
+	e.distance = positiveOr(opts.EventDistance, defaultEventDistance)
+	e.size = positiveOr(opts.EventSize, defaultEventSize)
+	e.titleCards = opts.EventTitleCards
+}
+
+// Init implements PlanIter and initializes the Events object.
+func (e *Events) Init(source AssetClient, albums []immich.Album) {
+	if e.size <= 0 {
+		e.Configure(Options{})
+	}
+	e.queue = nil
+	e.Update(source, albums)
+}
+
+// Update implements UpdatablePlanIter. The events are regrouped and a new
+// round of events starts after the current event is finished.
+func (e *Events) Update(source AssetClient, albums []immich.Album) {
+	e.events = e.group(getAllAlbumAssets(source, albums))
+	e.eventIndex = len(e.events)
+}
+
+// Next implements PlanIter and retrieves the next AssetMetadata, or the title
+// card of the next event.
+func (e *Events) Next() *immich.AssetMetadata {
+	if len(e.queue) == 0 {
+		if len(e.events) == 0 {
+			return nil
+		}
+		if e.eventIndex >= len(e.events) {
+			e.eventIndex = 0
+			rand.Shuffle(len(e.events), func(i, j int) {
+				e.events[i], e.events[j] = e.events[j], e.events[i]
+			})
+		}
+		event := e.events[e.eventIndex]
+		e.eventIndex++
+		e.queue = e.sample(event)
+		if e.titleCards {
+			return titleCard(event)
+		}
+	}
+	md := e.queue[0]
+	e.queue = e.queue[1:]
+	return &md
+}
+
+// Status implements StatusPlanIter and reports the position in the current
+// round of events.
+func (e *Events) Status() Status {
+	return Status{
+		Name:     e.Name(),
+		Position: e.eventIndex,
+		Total:    len(e.events),
+	}
+}
+
+// group is a helper method to sort the assets by date and split them into
+// events wherever the gap in time or distance between two assets is too
+// large. Distance is only compared if both assets have GPS coordinates.
+func (e *Events) group(assets []immich.AssetMetadata) [][]immich.AssetMetadata {
+	seen := make(map[immich.AssetID]bool)
+	var dated []datedAsset
+	for _, md := range assets {
+		date, ok := assetTime(md)
+		if !ok || seen[md.ID] {
+			continue
+		}
+		seen[md.ID] = true
+		dated = append(dated, datedAsset{md: md, date: date})
+	}
+	slices.SortStableFunc(dated, func(a, b datedAsset) int { return a.date.Compare(b.date) })
+
+	var events [][]immich.AssetMetadata
+	for i, da := range dated {
+		if i == 0 || da.date.Sub(dated[i-1].date) > e.gap || distance(dated[i-1].md, da.md) > e.distance {
+			events = append(events, nil)
+		}
+		events[len(events)-1] = append(events[len(events)-1], da.md)
+	}
+	return events
+}
+
+// sample is a helper method to randomly choose up to the configured number of
+// the event's assets, keeping them in chronological order.
+func (e *Events) sample(event []immich.AssetMetadata) []immich.AssetMetadata {
+	if len(event) <= e.size {
+		return slices.Clone(event)
+	}
+	indexes := rand.Perm(len(event))[:e.size]
+	slices.Sort(indexes)
+	sample := make([]immich.AssetMetadata, len(indexes))
+	for i, index := range indexes {
+		sample[i] = event[index]
+	}
+	return sample
+}
+
+// titleCard is a helper function to create the title card for an event, with
+// its location and dates as the lines of text. The ExifInfo of the first
+// asset is used so the card has the event's location and start date.
+func titleCard(event []immich.AssetMetadata) *immich.AssetMetadata {
+	location, dates := formatters.FormatEvent(event)
+	var lines []string
+	for _, line := range []string{location, dates} {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return &immich.AssetMetadata{
+		ID:       immich.AssetID(fmt.Sprintf("title-%s", event[0].ID)),
+		Type:     TitleCardType,
+		Name:     strings.Join(lines, "\n"),
+		ExifInfo: event[0].ExifInfo,
+	}
+}
+
+// distance is a helper function to calculate the distance in kilometers
+// between where two assets were taken. It is 0 if either asset does not have
+// GPS coordinates.
+func distance(a, b immich.AssetMetadata) float64 {
+	hasGPS := func(md immich.AssetMetadata) bool {
+		return md.ExifInfo.Latitude != 0 || md.ExifInfo.Longitude != 0
+	}
+	if !hasGPS(a) || !hasGPS(b) {
+		return 0
+	}
+	// Haversine formula.
+	const earthRadius = 6371
+	rad := func(deg float32) float64 { return float64(deg) * math.Pi / 180 }
+	lat1, lat2 := rad(a.ExifInfo.Latitude), rad(b.ExifInfo.Latitude)
+	dLat, dLon := lat2-lat1, rad(b.ExifInfo.Longitude)-rad(a.ExifInfo.Longitude)
+	h := math.Pow(math.Sin(dLat/2), 2) + math.Cos(lat1)*math.Cos(lat2)*math.Pow(math.Sin(dLon/2), 2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(h))
+}
+
+// positiveOr is a helper function to get the value, or the fallback if the
+// value is not positive.
+func positiveOr[T int | float64 | time.Duration](value, fallback T) T {
+	if value > 0 {
+		return value
+	}
+	return fallback
+}
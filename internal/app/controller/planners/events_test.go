@@ -0,0 +1,79 @@
+package planners_test
+
+import (
+	"testing"
+
+	"immich-photo-frame/internal/app/controller/planners"
+	"immich-photo-frame/internal/immich"
+)
+
+// testEventsClient is a helper function to create an AssetClient with two
+// events: a day in Paris, and a day in Lyon the next morning.
+func testEventsClient() testAssetClient {
+	paris := immich.ExifInfo{City: "Paris", Country: "France", Latitude: 48.86, Longitude: 2.35}
+	lyon := immich.ExifInfo{City: "Lyon", Country: "France", Latitude: 45.76, Longitude: 4.84}
+	at := func(exif immich.ExifInfo, date string) immich.ExifInfo {
+		exif.DateTimeOriginal = date
+		return exif
+	}
+	return testAssetClient{
+		lut: map[immich.AlbumID][]immich.AssetMetadata{
+			"album-1": {
+				{ID: "paris-2", ExifInfo: at(paris, "2024-07-01T15:00:00.000Z")},
+				{ID: "paris-1", ExifInfo: at(paris, "2024-07-01T09:00:00.000Z")},
+				{ID: "paris-3", ExifInfo: at(paris, "2024-07-01T20:00:00.000Z")},
+				// Less than the gap later, but too far away.
+				{ID: "lyon-1", ExifInfo: at(lyon, "2024-07-02T08:00:00.000Z")},
+				{ID: "lyon-2", ExifInfo: at(lyon, "2024-07-02T10:00:00.000Z")},
+				{ID: "undated"},
+			},
+		},
+	}
+}
+
+// TestEvents tests Events shows the assets of each event together, in
+// chronological order, limited to the configured size.
+func TestEvents(t *testing.T) {
+	var events planners.Events
+	events.Configure(planners.Options{EventSize: 2})
+	events.Init(testEventsClient(), []immich.Album{{ID: "album-1"}})
+
+	ids := nextIDs(&events, 4)
+	// The Paris event is sampled to 2 assets, so find which event was first.
+	paris, lyon := ids[:2], ids[2:]
+	if ids[0] == "lyon-1" {
+		lyon, paris = ids[:2], ids[2:]
+	}
+	assertIDs(t, []immich.AssetID{"lyon-1", "lyon-2"}, lyon)
+	if paris[0] >= paris[1] || paris[0][:5] != "paris" || paris[1][:5] != "paris" {
+		t.Fatalf("expected 2 Paris assets in chronological order, got %v", paris)
+	}
+	if status := events.Status(); status.Total != 2 {
+		t.Fatalf("expected 2 events, got %+v", status)
+	}
+}
+
+// TestEventsTitleCards tests Events shows a title card with the location and
+// dates before each event.
+func TestEventsTitleCards(t *testing.T) {
+	var events planners.Events
+	events.Configure(planners.Options{EventTitleCards: true})
+	events.Init(testEventsClient(), []immich.Album{{ID: "album-1"}})
+
+	for range 2 {
+		card := events.Next()
+		if card.Type != planners.TitleCardType {
+			t.Fatalf("expected a title card, got %+v", card)
+		}
+		var expected []immich.AssetID
+		switch card.Name {
+		case "Paris, France\nJuly 1, 2024":
+			expected = []immich.AssetID{"paris-1", "paris-2", "paris-3"}
+		case "Lyon, France\nJuly 2, 2024":
+			expected = []immich.AssetID{"lyon-1", "lyon-2"}
+		default:
+			t.Fatalf("unexpected title card %q", card.Name)
+		}
+		assertIDs(t, expected, nextIDs(&events, len(expected)))
+	}
+}
@@ -0,0 +1,199 @@
+package planners
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"immich-photo-frame/internal/immich"
+)
+
+// Filter decides which assets are included in a [Pipeline].
+type Filter interface {
+	Include(md immich.AssetMetadata) bool
+}
+
+// filterTypes is a LUT of the name used in the "type" key of a filter's TOML
+// table to a constructor for the Filter.
+var filterTypes = map[string]func() Filter{
+	"type":           func() Filter { return new(TypeFilter) },
+	"date-range":     func() Filter { return new(DateRangeFilter) },
+	"people":         func() Filter { return new(PeopleFilter) },
+	"location":       func() Filter { return new(LocationFilter) },
+	"orientation":    func() Filter { return new(OrientationFilter) },
+	"min-resolution": func() Filter { return new(MinResolutionFilter) },
+}
+
+// decodeFilter is a helper function to decode a filter from its JSON
+// representation, using its "type" key to pick the Filter.
+func decodeFilter(data []byte) (Filter, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	var filterType string
+	if err := json.Unmarshal(fields["type"], &filterType); err != nil {
+		return nil, errors.New(`filter is missing its "type"`)
+	}
+	newFilter, ok := filterTypes[strings.ToLower(filterType)]
+	if !ok {
+		var validTypes []string
+		for name := range filterTypes {
+			validTypes = append(validTypes, fmt.Sprintf("%q", name))
+		}
+		slices.Sort(validTypes)
+		return nil, fmt.Errorf(
+			"unsupported filter type %q, expected one of %v",
+			filterType, validTypes,
+		)
+	}
+	delete(fields, "type")
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	filter := newFilter()
+	if err := decodeStrict(data, filter); err != nil {
+		return nil, fmt.Errorf("invalid %q filter: %w", filterType, err)
+	}
+	return filter, nil
+}
+
+// TypeFilter includes assets of the listed immich types, such as "IMAGE" or
+// "VIDEO".
+type TypeFilter struct {
+	Types []string `json:"types"`
+}
+
+func (f TypeFilter) Include(md immich.AssetMetadata) bool {
+	return slices.ContainsFunc(f.Types, func(t string) bool {
+		return strings.EqualFold(t, md.Type)
+	})
+}
+
+// DateRangeFilter includes assets taken on or after the After date and on or
+// before the Before date, by their EXIF date in their own timezone. Either
+// date can be omitted. Assets without a date are not included.
+type DateRangeFilter struct {
+	After  Date `json:"after"`
+	Before Date `json:"before"`
+}
+
+func (f DateRangeFilter) Include(md immich.AssetMetadata) bool {
+	t, ok := assetTime(md)
+	if !ok {
+		return false
+	}
+	day := t.Format(time.DateOnly)
+	return (f.After.IsZero() || day >= f.After.Format(time.DateOnly)) &&
+		(f.Before.IsZero() || day <= f.Before.Format(time.DateOnly))
+}
+
+// PeopleFilter includes assets with at least one of the With people, if any,
+// and without any of the Without people. People are matched by their immich
+// name, ignoring case, or by their immich ID.
+type PeopleFilter struct {
+	With    []string `json:"include"`
+	Without []string `json:"exclude"`
+}
+
+func (f PeopleFilter) Include(md immich.AssetMetadata) bool {
+	matches := func(list []string) bool {
+		return slices.ContainsFunc(md.People, func(person immich.Person) bool {
+			return slices.ContainsFunc(list, func(name string) bool {
+				return string(person.ID) == name || (person.Name != "" && strings.EqualFold(person.Name, name))
+			})
+		})
+	}
+	return (len(f.With) == 0 || matches(f.With)) && !matches(f.Without)
+}
+
+// LocationFilter includes assets taken in any of the listed cities, states,
+// or countries, according to their EXIF data.
+type LocationFilter struct {
+	Cities    []string `json:"cities"`
+	States    []string `json:"states"`
+	Countries []string `json:"countries"`
+}
+
+func (f LocationFilter) Include(md immich.AssetMetadata) bool {
+	matches := func(list []string, value string) bool {
+		return value != "" && slices.ContainsFunc(list, func(v string) bool { return strings.EqualFold(v, value) })
+	}
+	return matches(f.Cities, md.ExifInfo.City) ||
+		matches(f.States, md.ExifInfo.State) ||
+		matches(f.Countries, md.ExifInfo.Country)
+}
+
+// OrientationFilter includes assets with the Orientation "portrait",
+// "landscape", or "square", by their EXIF dimensions. Assets without
+// dimensions are not included.
+type OrientationFilter struct {
+	Orientation string `json:"orientation"`
+}
+
+func (f OrientationFilter) Include(md immich.AssetMetadata) bool {
+	w, h := md.ExifInfo.Dimensions()
+	if w <= 0 || h <= 0 {
+		return false
+	}
+	switch strings.ToLower(f.Orientation) {
+	case "portrait":
+		return h > w
+	case "landscape":
+		return w > h
+	case "square":
+		return w == h
+	}
+	return false
+}
+
+// MinResolutionFilter includes assets that are at least Width pixels wide and
+// Height pixels tall, by their EXIF dimensions. Assets without dimensions are
+// not included.
+type MinResolutionFilter struct {
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+func (f MinResolutionFilter) Include(md immich.AssetMetadata) bool {
+	w, h := md.ExifInfo.Dimensions()
+	return w > 0 && h > 0 && w >= f.Width && h >= f.Height
+}
+
+// Date is a calendar date that can be decoded from a TOML date or a string in
+// the format "2006-01-02".
+type Date struct {
+	time.Time
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *Date) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	for _, layout := range []string{time.DateOnly, time.RFC3339Nano} {
+		if t, err := time.Parse(layout, s); err == nil {
+			d.Time = t
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid date %q, expected the format \"2006-01-02\"", s)
+}
+
+// assetTime is a helper function to get when the asset was taken, in the
+// asset's timezone if it is known.
+func assetTime(md immich.AssetMetadata) (time.Time, bool) {
+	t, err := time.Parse("2006-01-02T15:04:05.999Z07:00", md.ExifInfo.DateTimeOriginal)
+	if err != nil {
+		return time.Time{}, false
+	}
+	if loc, err := time.LoadLocation(md.ExifInfo.TimeZone); err == nil {
+		t = t.In(loc)
+	}
+	return t, true
+}
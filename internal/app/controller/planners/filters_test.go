@@ -0,0 +1,102 @@
+package planners_test
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"immich-photo-frame/internal/app/controller/planners"
+	"immich-photo-frame/internal/immich"
+)
+
+// loadFixture is a helper function to decode a JSON list of immich asset
+// responses from testdata.
+func loadFixture(t *testing.T, name string) []immich.AssetMetadata {
+	t.Helper()
+	data, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var assets []immich.AssetMetadata
+	if err := json.Unmarshal(data, &assets); err != nil {
+		t.Fatalf("failed to decode %s: %v", name, err)
+	}
+	return assets
+}
+
+// TestPeopleFixture tests people and their faces are decoded from immich
+// asset responses.
+func TestPeopleFixture(t *testing.T) {
+	assets := loadFixture(t, "people.json")
+	if len(assets) != 4 {
+		t.Fatalf("expected 4 assets, got %d", len(assets))
+	}
+	people := assets[0].People
+	if len(people) != 2 || people[0].Name != "Emma" || people[1].Name != "Grandpa" {
+		t.Fatalf("expected Emma and Grandpa, got %+v", people)
+	}
+	if people[0].ID != "7d6c0b9a-1a2b-4c3d-8e9f-000000000001" {
+		t.Errorf("unexpected person ID %q", people[0].ID)
+	}
+	if len(people[0].Faces) != 1 {
+		t.Fatalf("expected 1 face, got %d", len(people[0].Faces))
+	}
+	x1, y1, x2, y2, ok := people[0].Faces[0].Region()
+	if !ok || x1 != 0.25 || y1 != 0.25 || x2 != 0.5 || y2 != 0.5 {
+		t.Errorf("expected face region (0.25, 0.25) to (0.5, 0.5), got (%v, %v) to (%v, %v) %t", x1, y1, x2, y2, ok)
+	}
+	if len(assets[3].People) != 0 {
+		t.Errorf("expected no people, got %+v", assets[3].People)
+	}
+}
+
+// TestPeopleFilterFixture tests the people filter against immich asset
+// responses.
+func TestPeopleFilterFixture(t *testing.T) {
+	client := testAssetClient{lut: map[immich.AlbumID][]immich.AssetMetadata{
+		"album-1": loadFixture(t, "people.json"),
+	}}
+	for _, tt := range []struct {
+		name     string
+		filter   planners.PeopleFilter
+		expected []immich.AssetID
+	}{
+		{
+			name:   "include names",
+			filter: planners.PeopleFilter{With: []string{"emma", "Liam"}},
+			expected: []immich.AssetID{
+				"1b8a1f3e-6f0e-4c55-9a4e-6f1d2c7b0a01", "1b8a1f3e-6f0e-4c55-9a4e-6f1d2c7b0a02",
+			},
+		},
+		{
+			name:   "exclude name",
+			filter: planners.PeopleFilter{Without: []string{"Grandpa"}},
+			expected: []immich.AssetID{
+				"1b8a1f3e-6f0e-4c55-9a4e-6f1d2c7b0a02", "1b8a1f3e-6f0e-4c55-9a4e-6f1d2c7b0a03",
+				"1b8a1f3e-6f0e-4c55-9a4e-6f1d2c7b0a04",
+			},
+		},
+		{
+			name: "include and exclude",
+			filter: planners.PeopleFilter{
+				With:    []string{"Emma", "Liam"},
+				Without: []string{"Grandpa"},
+			},
+			expected: []immich.AssetID{"1b8a1f3e-6f0e-4c55-9a4e-6f1d2c7b0a02"},
+		},
+		{
+			name:     "include unnamed person by ID",
+			filter:   planners.PeopleFilter{With: []string{"7d6c0b9a-1a2b-4c3d-8e9f-000000000004"}},
+			expected: []immich.AssetID{"1b8a1f3e-6f0e-4c55-9a4e-6f1d2c7b0a03"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			planIter := planners.NewPipeline(new(planners.Sequential), tt.filter)
+			planIter.Init(client, []immich.Album{{ID: "album-1"}})
+			assertIDs(t, tt.expected, nextIDs(planIter, len(tt.expected)))
+			if md := planIter.Next(); md.ID != tt.expected[0] {
+				t.Fatalf("expected %q after starting over, got %q", tt.expected[0], md.ID)
+			}
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package planners
+
+import (
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"immich-photo-frame/internal/immich"
+)
+
+// Memories implements PlanIter by showing the assets in the configured albums
+// that were taken on this day in previous years. It uses the immich memories
+// for the day, limited to the configured albums. If there are none, it finds
+// the assets in the configured albums whose EXIF date matches today's month
+// and day. If there are still none, the configured fallback plan algorithm is
+// used until the day changes.
+type Memories struct {
+	fallback PlanIter
+	source   AssetClient
+	albums   []immich.Album
+	// day is midnight of the day the memories were loaded for.
+	day           time.Time
+	assets        []immich.AssetMetadata
+	assetIndex    int
+	usingFallback bool
+}
+
+func (m *Memories) Name() string { return "memories" }
+
+// Configure implements ConfigurablePlanIter and creates the fallback plan
+// algorithm, configured with the same options. Each Memories gets its own
+// instance of the fallback, so plans using it don't share a position. Shuffle
+// is used if there is none, or if it is Memories itself.
+func (m *Memories) Configure(opts Options) {
+	name := "shuffle"
+	if opts.MemoriesFallback.PlanIter != nil && opts.MemoriesFallback.Name() != m.Name() {
+		name = opts.MemoriesFallback.Name()
+	}
+	fallback, err := newPlanIter(name)
+	if err != nil {
+		slog.Error("failed to create memories fallback, using shuffle", "fallback", name, "error", err)
+		fallback = new(Shuffle)
+	}
+	if planIter, ok := fallback.(ConfigurablePlanIter); ok {
+		planIter.Configure(opts)
+	}
+	m.fallback = fallback
+}
+
+// Init implements PlanIter and loads the memories for today.
+func (m *Memories) Init(source AssetClient, albums []immich.Album) {
+	fallback := m.fallback
+	if fallback == nil {
+		fallback = new(Shuffle)
+	}
+	*m = Memories{
+		fallback: fallback,
+		source:   source,
+		albums:   albums,
+		day:      today(),
+	}
+	m.assets = m.memoryAssets()
+	if len(m.assets) > 0 {
+		slog.Info("loaded memories", "day", m.day.Format(time.DateOnly), "asset_count", len(m.assets))
+		return
+	}
+	slog.Info("no memories found, using fallback plan algorithm",
+		"day", m.day.Format(time.DateOnly),
+		"fallback", m.fallback.Name(),
+	)
+	m.fallback.Init(source, albums)
+	m.usingFallback = true
+}
+
+// Update implements UpdatablePlanIter. The memories are reloaded for the new
+// albums, continuing after the last returned asset if it is still a memory.
+// While the fallback plan algorithm is used, it is updated instead, unless the
+// new albums have memories for the day.
+func (m *Memories) Update(source AssetClient, albums []immich.Album) {
+	if !today().Equal(m.day) || m.fallback == nil {
+		m.Init(source, albums)
+		return
+	}
+	var last immich.AssetID
+	if !m.usingFallback && len(m.assets) > 0 {
+		last = m.assets[(m.assetIndex+len(m.assets)-1)%len(m.assets)].ID
+	}
+	m.source = source
+	m.albums = albums
+	assets := m.memoryAssets()
+	if len(assets) == 0 {
+		m.assets = nil
+		m.assetIndex = 0
+		if planIter, ok := m.fallback.(UpdatablePlanIter); ok && m.usingFallback {
+			planIter.Update(source, albums)
+			return
+		}
+		slog.Info("no memories found, using fallback plan algorithm",
+			"day", m.day.Format(time.DateOnly),
+			"fallback", m.fallback.Name(),
+		)
+		m.fallback.Init(source, albums)
+		m.usingFallback = true
+		return
+	}
+	m.usingFallback = false
+	m.assets = assets
+	m.assetIndex = (slices.IndexFunc(assets, func(md immich.AssetMetadata) bool {
+		return md.ID == last
+	}) + 1) % len(assets)
+}
+
+// Next implements PlanIter and retrieves the next AssetMetadata. The memories
+// are reloaded when the day changes.
+func (m *Memories) Next() *immich.AssetMetadata {
+	if !today().Equal(m.day) {
+		slog.Info("day changed, reloading memories")
+		m.Init(m.source, m.albums)
+	}
+	if m.usingFallback {
+		return m.fallback.Next()
+	}
+	if len(m.assets) == 0 {
+		return nil
+	}
+	md := m.assets[m.assetIndex]
+	m.assetIndex = (m.assetIndex + 1) % len(m.assets)
+	return &md
+}
+
+// Status implements StatusPlanIter. While the fallback plan algorithm is used,
+// its status is reported instead.
+func (m *Memories) Status() Status {
+	if m.usingFallback {
+		if planIter, ok := m.fallback.(StatusPlanIter); ok {
+			return planIter.Status()
+		}
+		return Status{Name: m.fallback.Name()}
+	}
+	return Status{
+		Name:     m.Name(),
+		Position: m.assetIndex,
+		Total:    len(m.assets),
+	}
+}
+
+// memoryAssets is a helper method to find the assets in the configured albums
+// for today's memories, oldest first. The album asset metadata is used since
+// it is more complete than the memory asset metadata.
+func (m *Memories) memoryAssets() []immich.AssetMetadata {
+	albumAssets := getAllAlbumAssets(m.source, m.albums)
+	assetsByID := make(map[immich.AssetID]immich.AssetMetadata, len(albumAssets))
+	for _, md := range albumAssets {
+		assetsByID[md.ID] = md
+	}
+
+	var assets []immich.AssetMetadata
+	memories, err := m.source.GetMemories(m.day)
+	if err != nil {
+		slog.Error("failed to get memories", "error", err)
+	}
+	for _, memory := range memories {
+		for _, md := range memory.Assets {
+			if md, ok := assetsByID[md.ID]; ok {
+				assets = append(assets, md)
+				delete(assetsByID, md.ID)
+			}
+		}
+	}
+	if len(assets) == 0 {
+		for _, md := range albumAssets {
+			if _, ok := assetsByID[md.ID]; ok && takenOnDay(md, m.day) {
+				assets = append(assets, md)
+				delete(assetsByID, md.ID)
+			}
+		}
+	}
+	slices.SortStableFunc(assets, func(a, b immich.AssetMetadata) int {
+		return strings.Compare(a.ExifInfo.DateTimeOriginal, b.ExifInfo.DateTimeOriginal)
+	})
+	return assets
+}
+
+// today is a helper function to get midnight of the current day.
+func today() time.Time {
+	y, m, d := time.Now().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+}
+
+// takenOnDay is a helper function to check if the asset was taken on the same
+// month and day as day, in a previous year. The date is compared in the
+// asset's timezone if it is known.
+func takenOnDay(md immich.AssetMetadata, day time.Time) bool {
+	t, ok := assetTime(md)
+	if !ok {
+		return false
+	}
+	return t.Year() < day.Year() && t.Month() == day.Month() && t.Day() == day.Day()
+}
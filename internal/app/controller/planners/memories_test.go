@@ -0,0 +1,183 @@
+package planners_test
+
+import (
+	"testing"
+	"time"
+
+	"immich-photo-frame/internal/app/controller/planners"
+	"immich-photo-frame/internal/immich"
+)
+
+// yearsAgo is a helper function to format the current time, the provided
+// number of years ago, as an EXIF timestamp.
+func yearsAgo(years int) string {
+	return time.Now().AddDate(-years, 0, 0).UTC().Format("2006-01-02T15:04:05.000Z07:00")
+}
+
+// nextIDs is a helper function to get the IDs of the next n assets.
+func nextIDs(planIter planners.PlanIter, n int) []immich.AssetID {
+	var ids []immich.AssetID
+	for range n {
+		md := planIter.Next()
+		if md == nil {
+			return ids
+		}
+		ids = append(ids, md.ID)
+	}
+	return ids
+}
+
+// assertIDs is a helper function to compare asset IDs.
+func assertIDs(t *testing.T, expected, got []immich.AssetID) {
+	t.Helper()
+	if len(got) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Fatalf("expected %v, got %v", expected, got)
+		}
+	}
+}
+
+// TestMemories tests Memories only shows the immich memories that are in the
+// configured albums, oldest first.
+func TestMemories(t *testing.T) {
+	var memories planners.Memories
+	client := testAssetClient{
+		lut: map[immich.AlbumID][]immich.AssetMetadata{
+			"album-1": {
+				{ID: "asset-1", ExifInfo: immich.ExifInfo{DateTimeOriginal: yearsAgo(1)}},
+				{ID: "asset-2", ExifInfo: immich.ExifInfo{DateTimeOriginal: yearsAgo(3)}},
+				{ID: "asset-3"},
+			},
+		},
+		memories: []immich.Memory{
+			{Assets: []immich.AssetMetadata{{ID: "asset-1"}, {ID: "not-in-album"}}},
+			{Assets: []immich.AssetMetadata{{ID: "asset-2"}}},
+		},
+	}
+
+	memories.Init(client, []immich.Album{{ID: "album-1"}})
+	assertIDs(t, []immich.AssetID{"asset-2", "asset-1", "asset-2"}, nextIDs(&memories, 3))
+}
+
+// TestMemoriesDateFallback tests Memories finds assets taken on this day in
+// previous years when immich has no memories in the configured albums.
+func TestMemoriesDateFallback(t *testing.T) {
+	var memories planners.Memories
+	client := testAssetClient{
+		lut: map[immich.AlbumID][]immich.AssetMetadata{
+			"album-1": {
+				{ID: "asset-1", ExifInfo: immich.ExifInfo{DateTimeOriginal: yearsAgo(2)}},
+				{ID: "asset-2", ExifInfo: immich.ExifInfo{DateTimeOriginal: yearsAgo(0)}},
+				{ID: "asset-3", ExifInfo: immich.ExifInfo{
+					DateTimeOriginal: time.Now().AddDate(-1, 0, -2).UTC().Format(time.RFC3339),
+				}},
+			},
+			"album-2": {
+				{ID: "asset-1", ExifInfo: immich.ExifInfo{DateTimeOriginal: yearsAgo(2)}},
+			},
+		},
+		memories: []immich.Memory{
+			{Assets: []immich.AssetMetadata{{ID: "not-in-album"}}},
+		},
+	}
+
+	memories.Init(client, []immich.Album{{ID: "album-1"}, {ID: "album-2"}})
+	assertIDs(t, []immich.AssetID{"asset-1", "asset-1"}, nextIDs(&memories, 2))
+	if status := memories.Status(); status.Name != "memories" || status.Total != 1 {
+		t.Fatalf("unexpected status: %+v", status)
+	}
+}
+
+// TestMemoriesFallback tests Memories uses the configured fallback plan
+// algorithm when there are no memories.
+func TestMemoriesFallback(t *testing.T) {
+	var memories planners.Memories
+	client := testAssetClient{
+		lut: map[immich.AlbumID][]immich.AssetMetadata{
+			"album-1": {
+				{ID: "asset-1"},
+				{ID: "asset-2"},
+			},
+		},
+	}
+
+	memories.Configure(planners.Options{
+		MemoriesFallback: planners.PlanAlgorithm{PlanIter: new(planners.Sequential)},
+	})
+	memories.Init(client, []immich.Album{{ID: "album-1"}})
+	assertIDs(t, []immich.AssetID{"asset-1", "asset-2", "asset-1"}, nextIDs(&memories, 3))
+	if status := memories.Status(); status.Name != "sequential" {
+		t.Fatalf("expected the fallback status, got %+v", status)
+	}
+}
+
+// TestMemoriesFallbackConfigured tests each Memories gets its own instance of
+// the fallback plan algorithm, configured with the same options.
+func TestMemoriesFallbackConfigured(t *testing.T) {
+	client := testAssetClient{
+		lut: map[immich.AlbumID][]immich.AssetMetadata{
+			"album-1": {
+				{ID: "asset-1", ExifInfo: immich.ExifInfo{DateTimeOriginal: "2001-02-03T00:00:00.000Z"}},
+				{ID: "asset-2", ExifInfo: immich.ExifInfo{DateTimeOriginal: "2002-02-03T00:00:00.000Z"}},
+				{ID: "asset-3", ExifInfo: immich.ExifInfo{DateTimeOriginal: "2003-02-03T00:00:00.000Z"}},
+			},
+		},
+	}
+	opts := planners.Options{
+		MemoriesFallback:   planners.PlanAlgorithm{PlanIter: new(planners.Chronological)},
+		ChronologicalOrder: planners.SortOrderDesc,
+	}
+	if _, month, day := time.Now().Date(); month == time.February && day == 3 {
+		t.Skip("the assets are memories today")
+	}
+
+	var first, second planners.Memories
+	first.Configure(opts)
+	first.Init(client, []immich.Album{{ID: "album-1"}})
+	second.Configure(opts)
+	second.Init(client, []immich.Album{{ID: "album-1"}})
+	assertIDs(t, []immich.AssetID{"asset-3", "asset-2"}, nextIDs(&first, 2))
+	assertIDs(t, []immich.AssetID{"asset-3"}, nextIDs(&second, 1))
+	assertIDs(t, []immich.AssetID{"asset-1"}, nextIDs(&first, 1))
+}
+
+// TestMemoriesUpdate tests Memories continues after the last returned memory
+// when the albums are updated, and switches to the fallback plan algorithm
+// when there are no memories left.
+func TestMemoriesUpdate(t *testing.T) {
+	var memories planners.Memories
+	client := testAssetClient{
+		lut: map[immich.AlbumID][]immich.AssetMetadata{
+			"album-1": {
+				{ID: "asset-1", ExifInfo: immich.ExifInfo{DateTimeOriginal: yearsAgo(3)}},
+				{ID: "asset-3", ExifInfo: immich.ExifInfo{DateTimeOriginal: yearsAgo(1)}},
+			},
+			"album-2": {
+				{ID: "asset-2", ExifInfo: immich.ExifInfo{DateTimeOriginal: yearsAgo(2)}},
+				{ID: "asset-4"},
+			},
+			"album-3": {
+				{ID: "asset-5"},
+				{ID: "asset-6"},
+			},
+		},
+	}
+
+	memories.Configure(planners.Options{
+		MemoriesFallback: planners.PlanAlgorithm{PlanIter: new(planners.Sequential)},
+	})
+	memories.Init(client, []immich.Album{{ID: "album-1"}})
+	assertIDs(t, []immich.AssetID{"asset-1"}, nextIDs(&memories, 1))
+
+	memories.Update(client, []immich.Album{{ID: "album-1"}, {ID: "album-2"}})
+	assertIDs(t, []immich.AssetID{"asset-2", "asset-3", "asset-1"}, nextIDs(&memories, 3))
+
+	memories.Update(client, []immich.Album{{ID: "album-3"}})
+	assertIDs(t, []immich.AssetID{"asset-5", "asset-6"}, nextIDs(&memories, 2))
+	if status := memories.Status(); status.Name != "sequential" {
+		t.Fatalf("expected the fallback status, got %+v", status)
+	}
+}
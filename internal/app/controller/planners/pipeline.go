@@ -0,0 +1,246 @@
+package planners
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"immich-photo-frame/internal/immich"
+)
+
+// Pipeline implements PlanIter by running a source PlanIter on only the assets
+// that pass every filter. The source never sees the other assets, so its
+// order and status only account for the included assets. Pipeline implements
+// all the optional PlanIter interfaces by delegating to the source when it
+// implements them.
+type Pipeline struct {
+	source  PlanIter
+	filters []Filter
+	// defaultTypes is the TypeFilter added by NewPipeline when none was
+	// provided, or nil.
+	defaultTypes *TypeFilter
+}
+
+// NewPipeline initializes a Pipeline. Unless a [TypeFilter] is provided, only
+// images are included.
+func NewPipeline(source PlanIter, filters ...Filter) *Pipeline {
+	hasTypeFilter := slices.ContainsFunc(filters, func(f Filter) bool {
+		_, ok := f.(*TypeFilter)
+		return ok
+	})
+	var defaultTypes *TypeFilter
+	if !hasTypeFilter {
+		defaultTypes = &TypeFilter{Types: []string{"IMAGE"}}
+		filters = append([]Filter{defaultTypes}, filters...)
+	}
+	return &Pipeline{source: source, filters: filters, defaultTypes: defaultTypes}
+}
+
+// IncludeVideos includes videos along with images if the Pipeline was created
+// without a [TypeFilter]. A provided TypeFilter is left as is. It must be
+// called before Init.
+func (p *Pipeline) IncludeVideos() {
+	if p.defaultTypes != nil && !slices.Contains(p.defaultTypes.Types, "VIDEO") {
+		p.defaultTypes.Types = append(p.defaultTypes.Types, "VIDEO")
+	}
+}
+
+// Name implements PlanIter and returns the name of the source.
+func (p *Pipeline) Name() string { return p.source.Name() }
+
+// Configure implements ConfigurablePlanIter.
+func (p *Pipeline) Configure(opts Options) {
+	if planIter, ok := p.source.(ConfigurablePlanIter); ok {
+		planIter.Configure(opts)
+	}
+}
+
+// Init implements PlanIter.
+func (p *Pipeline) Init(source AssetClient, albums []immich.Album) {
+	p.source.Init(p.filtered(source), albums)
+}
+
+// Update implements UpdatablePlanIter. The source is re-initialized if it
+// does not implement UpdatablePlanIter.
+func (p *Pipeline) Update(source AssetClient, albums []immich.Album) {
+	if planIter, ok := p.source.(UpdatablePlanIter); ok {
+		planIter.Update(p.filtered(source), albums)
+		return
+	}
+	p.source.Init(p.filtered(source), albums)
+}
+
+// Next implements PlanIter.
+func (p *Pipeline) Next() *immich.AssetMetadata {
+	return p.source.Next()
+}
+
+// NextDeferred implements DeferredPlanIter. If the source does not implement
+// DeferredPlanIter, the asset is retrieved right away.
+func (p *Pipeline) NextDeferred() func() *immich.AssetMetadata {
+	if planIter, ok := p.source.(DeferredPlanIter); ok {
+		return planIter.NextDeferred()
+	}
+	md := p.source.Next()
+	return func() *immich.AssetMetadata { return md }
+}
+
+// UncachedAlbumAssets implements UncachedPlanIter.
+func (p *Pipeline) UncachedAlbumAssets() bool {
+	planIter, ok := p.source.(UncachedPlanIter)
+	return ok && planIter.UncachedAlbumAssets()
+}
+
+// Status implements StatusPlanIter.
+func (p *Pipeline) Status() Status {
+	if planIter, ok := p.source.(StatusPlanIter); ok {
+		return planIter.Status()
+	}
+	return Status{Name: p.Name()}
+}
+
+// SaveState implements StatefulPlanIter. An error is returned if the source
+// does not implement StatefulPlanIter.
+func (p *Pipeline) SaveState() ([]byte, error) {
+	if planIter, ok := p.source.(StatefulPlanIter); ok {
+		return planIter.SaveState()
+	}
+	return nil, fmt.Errorf("plan algorithm %q does not have state", p.Name())
+}
+
+// RestoreState implements StatefulPlanIter. An error is returned if the
+// source does not implement StatefulPlanIter.
+func (p *Pipeline) RestoreState(source AssetClient, albums []immich.Album, state []byte) error {
+	if planIter, ok := p.source.(StatefulPlanIter); ok {
+		return planIter.RestoreState(p.filtered(source), albums, state)
+	}
+	return fmt.Errorf("plan algorithm %q does not have state", p.Name())
+}
+
+// filtered is a helper method to wrap the AssetClient so it only returns
+// assets that pass the filters.
+func (p *Pipeline) filtered(source AssetClient) AssetClient {
+	return filteredClient{AssetClient: source, filters: p.filters}
+}
+
+// filteredClient is an AssetClient that only returns the assets of the
+// wrapped AssetClient that pass every filter.
+type filteredClient struct {
+	AssetClient
+	filters []Filter
+}
+
+// GetAlbumAssets implements AssetClient.
+func (f filteredClient) GetAlbumAssets(id immich.AlbumID) ([]immich.AssetMetadata, error) {
+	mds, err := f.AssetClient.GetAlbumAssets(id)
+	if err != nil {
+		return nil, err
+	}
+	return f.filter(mds), nil
+}
+
+// GetAlbumAssetsUncached implements UncachedAssetClient. If the wrapped
+// AssetClient does not implement it, GetAlbumAssets is used.
+func (f filteredClient) GetAlbumAssetsUncached(id immich.AlbumID) ([]immich.AssetMetadata, error) {
+	mds, err := getAlbumAssetsUncached(f.AssetClient, id)
+	if err != nil {
+		return nil, err
+	}
+	return f.filter(mds), nil
+}
+
+// GetAssetMetadata implements AssetClient. An error is returned if the asset
+// does not pass the filters.
+func (f filteredClient) GetAssetMetadata(id immich.AssetID) (*immich.AssetMetadata, error) {
+	md, err := f.AssetClient.GetAssetMetadata(id)
+	if err != nil {
+		return nil, err
+	}
+	if !f.include(*md) {
+		return nil, fmt.Errorf("asset %q does not pass the filters", id)
+	}
+	return md, nil
+}
+
+// GetMemories implements AssetClient.
+func (f filteredClient) GetMemories(day time.Time) ([]immich.Memory, error) {
+	memories, err := f.AssetClient.GetMemories(day)
+	if err != nil {
+		return nil, err
+	}
+	filtered := make([]immich.Memory, len(memories))
+	for i, memory := range memories {
+		memory.Assets = f.filter(memory.Assets)
+		filtered[i] = memory
+	}
+	return filtered, nil
+}
+
+// include is a helper method to check if the asset passes every filter.
+func (f filteredClient) include(md immich.AssetMetadata) bool {
+	for _, filter := range f.filters {
+		if !filter.Include(md) {
+			return false
+		}
+	}
+	return true
+}
+
+// filter is a helper method to get the assets that pass every filter. A new
+// slice is returned since the assets may be shared with a cache.
+func (f filteredClient) filter(mds []immich.AssetMetadata) []immich.AssetMetadata {
+	var filtered []immich.AssetMetadata
+	for _, md := range mds {
+		if f.include(md) {
+			filtered = append(filtered, md)
+		}
+	}
+	return filtered
+}
+
+// pipelineConfig is the TOML table form of a PlanAlgorithm.
+type pipelineConfig struct {
+	Source  string            `json:"source"`
+	Filters []json.RawMessage `json:"filters"`
+}
+
+// decodePipeline is a helper function to decode the TOML table form of a
+// PlanAlgorithm into a Pipeline. The table is converted to JSON to decode it
+// into the filters.
+func decodePipeline(table map[string]any) (*Pipeline, error) {
+	data, err := json.Marshal(table)
+	if err != nil {
+		return nil, err
+	}
+	var conf pipelineConfig
+	if err := decodeStrict(data, &conf); err != nil {
+		return nil, fmt.Errorf("invalid plan algorithm: %w", err)
+	}
+	if conf.Source == "" {
+		return nil, errors.New(`plan algorithm is missing its "source"`)
+	}
+	source, err := newPlanIter(conf.Source)
+	if err != nil {
+		return nil, err
+	}
+	var filters []Filter
+	for _, raw := range conf.Filters {
+		filter, err := decodeFilter(raw)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, filter)
+	}
+	return NewPipeline(source, filters...), nil
+}
+
+// decodeStrict is a helper function to decode JSON into v, failing on unknown
+// fields so typos in the configuration are caught.
+func decodeStrict(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
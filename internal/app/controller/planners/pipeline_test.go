@@ -0,0 +1,164 @@
+package planners_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+
+	"immich-photo-frame/internal/app/controller/planners"
+	"immich-photo-frame/internal/immich"
+)
+
+// decodePlanAlgorithm is a helper function to decode a TOML plan algorithm.
+func decodePlanAlgorithm(t *testing.T, doc string) (planners.PlanAlgorithm, error) {
+	t.Helper()
+	var conf struct {
+		PlanAlgorithm planners.PlanAlgorithm
+	}
+	_, err := toml.Decode(doc, &conf)
+	return conf.PlanAlgorithm, err
+}
+
+// testPipelineClient is a helper function to create an AssetClient with a
+// variety of assets to filter.
+func testPipelineClient() testAssetClient {
+	return testAssetClient{
+		lut: map[immich.AlbumID][]immich.AssetMetadata{
+			"album-1": {
+				{ID: "video", Type: "VIDEO"},
+				{ID: "paris-portrait", Type: "IMAGE", ExifInfo: immich.ExifInfo{
+					Country: "France", DateTimeOriginal: "2023-05-01T12:00:00.000Z",
+					ExifImageWidth: 3000, ExifImageHeight: 4000,
+				}},
+				{ID: "paris-landscape", Type: "IMAGE", ExifInfo: immich.ExifInfo{
+					Country: "France", DateTimeOriginal: "2024-05-01T12:00:00.000Z",
+					ExifImageWidth: 4000, ExifImageHeight: 3000,
+				}},
+				{ID: "rotated-portrait", Type: "IMAGE", ExifInfo: immich.ExifInfo{
+					Country: "Italy", DateTimeOriginal: "2024-06-01T12:00:00.000Z",
+					ExifImageWidth: 4000, ExifImageHeight: 3000, Orientation: "6",
+				}},
+				{ID: "small", Type: "IMAGE", ExifInfo: immich.ExifInfo{
+					Country: "France", DateTimeOriginal: "2024-07-01T12:00:00.000Z",
+					ExifImageWidth: 400, ExifImageHeight: 300,
+				}},
+				{ID: "alice", Type: "IMAGE", People: []immich.Person{{Name: "Alice"}}},
+				{ID: "alice-and-bob", Type: "IMAGE", People: []immich.Person{{Name: "Alice"}, {Name: "Bob"}}},
+			},
+		},
+	}
+}
+
+// TestPipeline tests plan algorithms configured as a TOML table only show the
+// assets that pass every filter.
+func TestPipeline(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		doc      string
+		expected []immich.AssetID
+	}{
+		{
+			name: "name only includes images",
+			doc:  `planAlgorithm = "sequential"`,
+			expected: []immich.AssetID{
+				"paris-portrait", "paris-landscape", "rotated-portrait", "small", "alice", "alice-and-bob",
+			},
+		},
+		{
+			name:     "type",
+			doc:      `planAlgorithm = { source = "sequential", filters = [{ type = "type", types = ["video"] }] }`,
+			expected: []immich.AssetID{"video"},
+		},
+		{
+			name: "date range and location",
+			doc: `
+[planAlgorithm]
+source = "sequential"
+filters = [
+  { type = "date-range", after = 2024-01-01, before = "2024-06-30" },
+  { type = "location", countries = ["france", "Italy"] },
+]`,
+			expected: []immich.AssetID{"paris-landscape", "rotated-portrait"},
+		},
+		{
+			name: "orientation and resolution",
+			doc: `
+[planAlgorithm]
+source = "sequential"
+[[planAlgorithm.filters]]
+type = "orientation"
+orientation = "portrait"
+[[planAlgorithm.filters]]
+type = "min-resolution"
+width = 3000
+height = 4000`,
+			expected: []immich.AssetID{"paris-portrait", "rotated-portrait"},
+		},
+		{
+			name:     "people",
+			doc:      `planAlgorithm = { source = "sequential", filters = [{ type = "people", include = ["alice"], exclude = ["Bob"] }] }`,
+			expected: []immich.AssetID{"alice"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			algo, err := decodePlanAlgorithm(t, tt.doc)
+			if err != nil {
+				t.Fatalf("failed to decode plan algorithm: %v", err)
+			}
+			if algo.Name() != "sequential" {
+				t.Fatalf(`expected the "sequential" source, got %q`, algo.Name())
+			}
+			algo.Init(testPipelineClient(), []immich.Album{{ID: "album-1"}})
+			assertIDs(t, tt.expected, nextIDs(algo, len(tt.expected)))
+			// The source starts over without seeing the filtered assets.
+			if md := algo.Next(); md.ID != tt.expected[0] {
+				t.Fatalf("expected %q after starting over, got %q", tt.expected[0], md.ID)
+			}
+		})
+	}
+}
+
+// TestPipelineIncludeVideos tests IncludeVideos adds videos to the default
+// type filter, but leaves a configured type filter as is.
+func TestPipelineIncludeVideos(t *testing.T) {
+	for _, tt := range []struct {
+		doc      string
+		expected []immich.AssetID
+	}{
+		{`planAlgorithm = "sequential"`, []immich.AssetID{"video", "paris-portrait"}},
+		{
+			`planAlgorithm = { source = "sequential", filters = [{ type = "type", types = ["image"] }] }`,
+			[]immich.AssetID{"paris-portrait", "paris-landscape"},
+		},
+	} {
+		algo, err := decodePlanAlgorithm(t, tt.doc)
+		if err != nil {
+			t.Fatalf("failed to decode plan algorithm: %v", err)
+		}
+		algo.PlanIter.(*planners.Pipeline).IncludeVideos()
+		algo.Init(testPipelineClient(), []immich.Album{{ID: "album-1"}})
+		assertIDs(t, tt.expected, nextIDs(algo, len(tt.expected)))
+	}
+}
+
+// TestPipelineErrors tests invalid plan algorithm tables are reported.
+func TestPipelineErrors(t *testing.T) {
+	for _, tt := range []struct {
+		doc      string
+		expected string
+	}{
+		{`planAlgorithm = { filters = [] }`, `missing its "source"`},
+		{`planAlgorithm = { source = "nope" }`, `unsupported plan algorithm "nope"`},
+		{`planAlgorithm = { source = "shuffle", filter = [] }`, `unknown field "filter"`},
+		{`planAlgorithm = { source = "shuffle", filters = [{ type = "colour" }] }`, `unsupported filter type "colour"`},
+		{`planAlgorithm = { source = "shuffle", filters = [{ types = ["IMAGE"] }] }`, `missing its "type"`},
+		{`planAlgorithm = { source = "shuffle", filters = [{ type = "type", kinds = [] }] }`, `invalid "type" filter`},
+		{`planAlgorithm = { source = "shuffle", filters = [{ type = "date-range", after = "May" }] }`, `invalid date "May"`},
+	} {
+		_, err := decodePlanAlgorithm(t, tt.doc)
+		if err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("decoding %s: expected an error containing %q, got %v", tt.doc, tt.expected, err)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package planners
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"immich-photo-frame/internal/immich"
 )
@@ -14,10 +15,137 @@ type PlanIter interface {
 	Next() *immich.AssetMetadata
 }
 
+// UpdatablePlanIter is an optional interface for a PlanIter that can
+// incorporate a new set of albums without losing its position. A PlanIter
+// that does not implement it is re-initialized instead.
+type UpdatablePlanIter interface {
+	PlanIter
+	Update(source AssetClient, albums []immich.Album)
+}
+
+// StatusPlanIter is an optional interface for a PlanIter that can report its
+// progress through the configured albums and assets.
+type StatusPlanIter interface {
+	PlanIter
+	Status() Status
+}
+
+// StatefulPlanIter is an optional interface for a PlanIter that can save its
+// position so it continues where it left off after a restart. RestoreState is
+// called instead of Init and must reconcile the saved state with assets that
+// were added or removed since it was saved.
+type StatefulPlanIter interface {
+	PlanIter
+	SaveState() ([]byte, error)
+	RestoreState(source AssetClient, albums []immich.Album, state []byte) error
+}
+
+// DeferredPlanIter is an optional interface for a PlanIter that looks up the
+// metadata of each asset as it is returned. NextDeferred advances like Next,
+// but returns a function to look up the metadata, so it can be called without
+// holding the lock guarding the PlanIter. The function returns nil if the
+// metadata could not be retrieved.
+type DeferredPlanIter interface {
+	PlanIter
+	NextDeferred() func() *immich.AssetMetadata
+}
+
+// UncachedPlanIter is an optional interface for a PlanIter that only keeps part
+// of the album assets, so it retrieves them with UncachedAssetClient when the
+// source implements it. UncachedAlbumAssets reports whether it does, so album
+// assets fetched ahead of time for it can skip the in-memory cache too.
+type UncachedPlanIter interface {
+	PlanIter
+	UncachedAlbumAssets() bool
+}
+
+// ConfigurablePlanIter is an optional interface for a PlanIter that needs
+// more configuration than its name. Configure is called before Init.
+type ConfigurablePlanIter interface {
+	PlanIter
+	Configure(opts Options)
+}
+
+// Options holds configuration values for plan algorithms.
+//
+// It is organized to take advantage of TOML parsing, however this package does
+// not handle parsing and has no expectation on how it will be initialized.
+type Options struct {
+	// MemoriesFallback is used by [Memories] when there are no memories for
+	// the day.
+	MemoriesFallback PlanAlgorithm
+	// AlbumWeights is the relative weight of each album, by name, used by
+	// [Weighted].
+	AlbumWeights map[string]float64
+	// RecentBiasHalfLife is how much older an asset has to be to be shown
+	// half as often by [RecentBias].
+	RecentBiasHalfLife time.Duration
+	// RecentBiasRepeatWindow is how many of the most recently shown assets
+	// [RecentBias] will not repeat.
+	RecentBiasRepeatWindow int
+	// RecentBiasSeed seeds the random number generator of [RecentBias] so
+	// it is reproducible. A value of 0 uses a random seed.
+	RecentBiasSeed uint64
+	// ChronologicalOrder is the direction [Chronological] iterates the
+	// timeline.
+	ChronologicalOrder SortOrder
+	// EventGap is the amount of time between assets that starts a new event
+	// for [Events].
+	EventGap time.Duration
+	// EventDistance is the distance in kilometers between assets that
+	// starts a new event for [Events].
+	EventDistance float64
+	// EventSize is the maximum number of assets [Events] shows from each
+	// event.
+	EventSize int
+	// EventTitleCards shows a title card before each event from [Events].
+	EventTitleCards bool
+}
+
+// SortOrder is the direction assets are sorted by date.
+type SortOrder string
+
+const (
+	SortOrderAsc  SortOrder = "asc"
+	SortOrderDesc SortOrder = "desc"
+)
+
+// UnmarshalText implements toml.TextUnmarshaler.
+func (o *SortOrder) UnmarshalText(text []byte) error {
+	order := SortOrder(strings.ToLower(string(text)))
+	if order != SortOrderAsc && order != SortOrderDesc {
+		return fmt.Errorf(
+			"unsupported sort order %q, expected one of %v",
+			string(text), []string{`"asc"`, `"desc"`},
+		)
+	}
+	*o = order
+	return nil
+}
+
+// Status describes a PlanIter's progress.
+type Status struct {
+	Name     string `json:"name"`
+	Album    string `json:"album,omitempty"`
+	Position int    `json:"position"`
+	Total    int    `json:"total"`
+	Schedule string `json:"schedule,omitempty"`
+}
+
 // AssetClient describes an object that, given an AlbumID, can retrieve a list
-// of AssetMetadata.
+// of AssetMetadata, given an AssetID, can retrieve its AssetMetadata, and can
+// retrieve the immich memories for a day.
 type AssetClient interface {
 	GetAlbumAssets(immich.AlbumID) ([]immich.AssetMetadata, error)
+	GetAssetMetadata(immich.AssetID) (*immich.AssetMetadata, error)
+	GetMemories(day time.Time) ([]immich.Memory, error)
+}
+
+// UncachedAssetClient is an optional interface for an AssetClient that can
+// retrieve a list of AssetMetadata without keeping it in an in-memory cache.
+type UncachedAssetClient interface {
+	AssetClient
+	GetAlbumAssetsUncached(immich.AlbumID) ([]immich.AssetMetadata, error)
 }
 
 // PlanAlgorithm is a concrete object that embeds a PlanIter interface. This
@@ -28,31 +156,68 @@ type PlanAlgorithm struct {
 }
 
 var (
-	// planAlgorithms is the list of all the PlanIter objects that can be
-	// configured.
-	planAlgorithms = []PlanIter{
-		new(Sequential),
-		new(Shuffle),
+	// planAlgorithms is the list of constructors for all the PlanIter
+	// objects that can be configured. Each configured PlanAlgorithm gets its
+	// own PlanIter, so the same algorithm can be used more than once.
+	planAlgorithms = []func() PlanIter{
+		func() PlanIter { return new(Sequential) },
+		func() PlanIter { return new(Shuffle) },
+		func() PlanIter { return new(StreamingShuffle) },
+		func() PlanIter { return new(Memories) },
+		func() PlanIter { return new(Weighted) },
+		func() PlanIter { return new(RecentBias) },
+		func() PlanIter { return new(Chronological) },
+		func() PlanIter { return new(Events) },
 	}
 
-	// planAlgorithmsByName is a LUT of name to PlanIter, built via [init].
-	planAlgorithmsByName = map[string]PlanIter{}
+	// planAlgorithmsByName is a LUT of name to PlanIter constructor, built
+	// via [init].
+	planAlgorithmsByName = map[string]func() PlanIter{}
 )
 
-// UnmarshalText implements toml.TextUnmarshaler.
+// UnmarshalText implements toml.TextUnmarshaler. The PlanIter is wrapped in a
+// Pipeline without filters, so only images are included.
 func (p *PlanAlgorithm) UnmarshalText(text []byte) error {
-	iter, ok := planAlgorithmsByName[strings.ToLower(string(text))]
-	if ok {
-		p.PlanIter = iter
+	iter, err := newPlanIter(string(text))
+	if err != nil {
+		return err
+	}
+	p.PlanIter = NewPipeline(iter)
+	return nil
+}
+
+// UnmarshalTOML implements toml.Unmarshaler. A plan algorithm is either the
+// name of a PlanIter, or a table with the name as its "source" and a list of
+// "filters" to create a Pipeline.
+func (p *PlanAlgorithm) UnmarshalTOML(data any) error {
+	switch v := data.(type) {
+	case string:
+		return p.UnmarshalText([]byte(v))
+	case map[string]any:
+		pipeline, err := decodePipeline(v)
+		if err != nil {
+			return err
+		}
+		p.PlanIter = pipeline
 		return nil
 	}
+	return fmt.Errorf("unsupported plan algorithm %v, expected a name or a table", data)
+}
+
+// newPlanIter is a helper function to create the PlanIter with the provided
+// name.
+func newPlanIter(name string) (PlanIter, error) {
+	newIter, ok := planAlgorithmsByName[strings.ToLower(name)]
+	if ok {
+		return newIter(), nil
+	}
 	var validAlgos []string
 	for key := range planAlgorithmsByName {
 		validAlgos = append(validAlgos, fmt.Sprintf("%q", key))
 	}
-	return fmt.Errorf(
+	return nil, fmt.Errorf(
 		"unsupported plan algorithm %q, expected one of %v",
-		string(text), validAlgos,
+		name, validAlgos,
 	)
 }
 
@@ -62,7 +227,7 @@ func (p *PlanAlgorithm) MarshalJSON() ([]byte, error) {
 }
 
 func init() {
-	for _, algo := range planAlgorithms {
-		planAlgorithmsByName[algo.Name()] = algo
+	for _, newIter := range planAlgorithms {
+		planAlgorithmsByName[newIter().Name()] = newIter
 	}
 }
@@ -0,0 +1,157 @@
+package planners
+
+import (
+	"math"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"immich-photo-frame/internal/immich"
+)
+
+// defaultRecentBiasHalfLife is used by RecentBias when no half-life is
+// configured.
+const defaultRecentBiasHalfLife = 365 * 24 * time.Hour
+
+// RecentBias implements PlanIter by randomly sampling assets so that recent
+// assets are shown more often than old ones. An asset's weight halves for
+// every configured half-life of age, according to its EXIF date, so old
+// assets are still shown occasionally. Assets without a date are weighted
+// like the oldest asset. The most recently shown assets are not repeated
+// until they leave the configured repeat window.
+type RecentBias struct {
+	halfLife     time.Duration
+	repeatWindow int
+	rng          *rand.Rand
+	assets       []weightedAsset
+	// window holds the IDs of the most recently shown assets, oldest first,
+	// which are also tracked in inWindow.
+	window   []immich.AssetID
+	inWindow map[immich.AssetID]bool
+}
+
+// weightedAsset is an asset and how likely it is to be sampled.
+type weightedAsset struct {
+	md     immich.AssetMetadata
+	weight float64
+}
+
+func (r *RecentBias) Name() string { return "recent-bias" }
+
+// Configure implements ConfigurablePlanIter and sets the decay curve, repeat
+// window, and random seed.
+func (r *RecentBias) Configure(opts Options) {
+	r.halfLife = opts.RecentBiasHalfLife
+	r.repeatWindow = opts.RecentBiasRepeatWindow
+	seed := opts.RecentBiasSeed
+	if seed == 0 {
+		seed = rand.Uint64()
+	}
+	r.rng = rand.New(rand.NewPCG(seed, seed))
+}
+
+// Init implements PlanIter and initializes the RecentBias object.
+func (r *RecentBias) Init(source AssetClient, albums []immich.Album) {
+	if r.rng == nil {
+		r.Configure(Options{})
+	}
+	r.window = nil
+	r.inWindow = make(map[immich.AssetID]bool)
+	r.Update(source, albums)
+}
+
+// Update implements UpdatablePlanIter. The repeat window is kept, so recently
+// shown assets are still not repeated.
+func (r *RecentBias) Update(source AssetClient, albums []immich.Album) {
+	halfLife := r.halfLife
+	if halfLife <= 0 {
+		halfLife = defaultRecentBiasHalfLife
+	}
+	now := time.Now()
+	seen := make(map[immich.AssetID]bool)
+	r.assets = nil
+	minWeight := 1.0
+	for _, md := range getAllAlbumAssets(source, albums) {
+		if seen[md.ID] {
+			continue
+		}
+		seen[md.ID] = true
+		weight := math.NaN()
+		if t, ok := assetTime(md); ok {
+			age := max(now.Sub(t), 0)
+			weight = math.Exp2(-float64(age) / float64(halfLife))
+			minWeight = min(minWeight, weight)
+		}
+		r.assets = append(r.assets, weightedAsset{md: md, weight: weight})
+	}
+	for i := range r.assets {
+		if math.IsNaN(r.assets[i].weight) {
+			r.assets[i].weight = minWeight
+		}
+	}
+	// Forget removed assets so they do not take up room in the window.
+	r.window = slices.DeleteFunc(r.window, func(id immich.AssetID) bool {
+		if !seen[id] {
+			delete(r.inWindow, id)
+			return true
+		}
+		return false
+	})
+	r.trimWindow()
+}
+
+// Next implements PlanIter and samples the next AssetMetadata.
+func (r *RecentBias) Next() *immich.AssetMetadata {
+	var total float64
+	for _, wa := range r.assets {
+		if !r.inWindow[wa.md.ID] {
+			total += wa.weight
+		}
+	}
+	if total == 0 {
+		return nil
+	}
+	x := r.rng.Float64() * total
+	var md immich.AssetMetadata
+	for _, wa := range r.assets {
+		if r.inWindow[wa.md.ID] {
+			continue
+		}
+		md = wa.md
+		if x < wa.weight {
+			break
+		}
+		x -= wa.weight
+	}
+	r.remember(md.ID)
+	return &md
+}
+
+// Status implements StatusPlanIter. There is no position since assets are
+// sampled.
+func (r *RecentBias) Status() Status {
+	return Status{
+		Name:  r.Name(),
+		Total: len(r.assets),
+	}
+}
+
+// remember is a helper method to add the asset to the repeat window.
+func (r *RecentBias) remember(id immich.AssetID) {
+	r.window = append(r.window, id)
+	r.inWindow[id] = true
+	r.trimWindow()
+}
+
+// trimWindow is a helper method to remove the oldest assets from the repeat
+// window once it is full. The window is kept smaller than the number of
+// assets so there is always one to sample.
+func (r *RecentBias) trimWindow() {
+	size := max(min(r.repeatWindow, len(r.assets)-1), 0)
+	if n := len(r.window) - size; n > 0 {
+		for _, id := range r.window[:n] {
+			delete(r.inWindow, id)
+		}
+		r.window = slices.Delete(r.window, 0, n)
+	}
+}
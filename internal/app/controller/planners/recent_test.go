@@ -0,0 +1,85 @@
+package planners_test
+
+import (
+	"testing"
+	"time"
+
+	"immich-photo-frame/internal/app/controller/planners"
+	"immich-photo-frame/internal/immich"
+)
+
+// newTestRecentBias is a helper function to initialize a seeded RecentBias.
+func newTestRecentBias(client testAssetClient, halfLife time.Duration, repeatWindow int) *planners.RecentBias {
+	recent := new(planners.RecentBias)
+	recent.Configure(planners.Options{
+		RecentBiasHalfLife:     halfLife,
+		RecentBiasRepeatWindow: repeatWindow,
+		RecentBiasSeed:         42,
+	})
+	recent.Init(client, []immich.Album{{ID: "album-1"}})
+	return recent
+}
+
+// TestRecentBiasSeed tests RecentBias is reproducible with the same seed.
+func TestRecentBiasSeed(t *testing.T) {
+	client := testAssetClient{
+		lut: map[immich.AlbumID][]immich.AssetMetadata{
+			"album-1": {
+				{ID: "asset-1", ExifInfo: immich.ExifInfo{DateTimeOriginal: yearsAgo(0)}},
+				{ID: "asset-2", ExifInfo: immich.ExifInfo{DateTimeOriginal: yearsAgo(1)}},
+				{ID: "asset-3", ExifInfo: immich.ExifInfo{DateTimeOriginal: yearsAgo(2)}},
+				{ID: "asset-4"},
+			},
+		},
+	}
+	halfLife := 365 * 24 * time.Hour
+	expected := nextIDs(newTestRecentBias(client, halfLife, 1), 20)
+	assertIDs(t, expected, nextIDs(newTestRecentBias(client, halfLife, 1), 20))
+}
+
+// TestRecentBiasDecay tests RecentBias shows recent assets more often than old
+// assets according to the half-life, without excluding old assets.
+func TestRecentBiasDecay(t *testing.T) {
+	client := testAssetClient{
+		lut: map[immich.AlbumID][]immich.AssetMetadata{
+			"album-1": {
+				{ID: "new", ExifInfo: immich.ExifInfo{DateTimeOriginal: yearsAgo(0)}},
+				{ID: "old", ExifInfo: immich.ExifInfo{DateTimeOriginal: yearsAgo(2)}},
+			},
+		},
+	}
+	// With a half-life of 1 year, "old" has a quarter of the weight.
+	recent := newTestRecentBias(client, 365*24*time.Hour, 0)
+	counts := make(map[immich.AssetID]int)
+	for _, id := range nextIDs(recent, 5000) {
+		counts[id]++
+	}
+	if ratio := float64(counts["old"]) / float64(counts["new"]); ratio < 0.2 || ratio > 0.3 {
+		t.Fatalf(`expected "old" to be shown about 1/4 as often as "new", got %v`, counts)
+	}
+}
+
+// TestRecentBiasRepeatWindow tests RecentBias does not repeat assets within
+// the repeat window.
+func TestRecentBiasRepeatWindow(t *testing.T) {
+	client := testAssetClient{
+		lut: map[immich.AlbumID][]immich.AssetMetadata{
+			"album-1": {
+				{ID: "asset-1", ExifInfo: immich.ExifInfo{DateTimeOriginal: yearsAgo(0)}},
+				{ID: "asset-2", ExifInfo: immich.ExifInfo{DateTimeOriginal: yearsAgo(5)}},
+				{ID: "asset-3", ExifInfo: immich.ExifInfo{DateTimeOriginal: yearsAgo(10)}},
+				{ID: "asset-4", ExifInfo: immich.ExifInfo{DateTimeOriginal: yearsAgo(15)}},
+				{ID: "asset-5"},
+			},
+		},
+	}
+	const window = 3
+	ids := nextIDs(newTestRecentBias(client, 365*24*time.Hour, window), 200)
+	for i := range ids {
+		for j := max(i-window, 0); j < i; j++ {
+			if ids[i] == ids[j] {
+				t.Fatalf("%q was repeated within %d assets at index %d", ids[i], window, i)
+			}
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package planners
 
 import (
+	"encoding/json"
 	"log/slog"
 	"slices"
 
@@ -31,6 +32,81 @@ func (s *Sequential) Init(source AssetClient, albums []immich.Album) {
 	}
 }
 
+// Update implements UpdatablePlanIter. It continues from the last returned
+// asset of the current album if the album is still configured, otherwise it
+// continues with the album that took its place.
+func (s *Sequential) Update(source AssetClient, albums []immich.Album) {
+	st := s.state()
+	s.source = source
+	s.albums = albums
+	s.resume(st)
+}
+
+// sequentialState is the saved state of a Sequential.
+type sequentialState struct {
+	AlbumID immich.AlbumID `json:"albumId"`
+	AssetID immich.AssetID `json:"assetId"`
+}
+
+// SaveState implements StatefulPlanIter and saves the current album and the
+// last returned asset.
+func (s *Sequential) SaveState() ([]byte, error) {
+	return json.Marshal(s.state())
+}
+
+// RestoreState implements StatefulPlanIter and continues after the saved
+// asset. If the saved album is no longer configured, it starts over.
+func (s *Sequential) RestoreState(source AssetClient, albums []immich.Album, state []byte) error {
+	var st sequentialState
+	if err := json.Unmarshal(state, &st); err != nil {
+		return err
+	}
+	s.Init(source, albums)
+	s.resume(st)
+	return nil
+}
+
+// state is a helper method to get the current sequentialState.
+func (s *Sequential) state() sequentialState {
+	var st sequentialState
+	if s.albumIndex >= 0 && s.albumIndex < len(s.albums) {
+		st.AlbumID = s.albums[s.albumIndex].ID
+	}
+	if s.assetIndex > 0 && s.assetIndex <= len(s.assets) {
+		st.AssetID = s.assets[s.assetIndex-1].ID
+	}
+	return st
+}
+
+// resume is a helper method to continue after the album and asset in st,
+// using the configured albums.
+func (s *Sequential) resume(st sequentialState) {
+	s.assets = nil
+	s.assetIndex = 0
+
+	newAlbumIndex := slices.IndexFunc(s.albums, func(album immich.Album) bool {
+		return album.ID == st.AlbumID
+	})
+	if newAlbumIndex < 0 {
+		// The current album is gone, so start the album now at its
+		// position on the next call to Next().
+		s.albumIndex = max(min(s.albumIndex, len(s.albums)), 0) - 1
+		return
+	}
+	s.albumIndex = newAlbumIndex
+	assets, err := s.getAlbumAssetsInOrder(s.albums[newAlbumIndex])
+	if err != nil {
+		slog.Error("failed to load assets", "error", err)
+		return
+	}
+	s.assets = assets
+	// Resume after the last returned asset. If it was removed, start the
+	// album over.
+	s.assetIndex = slices.IndexFunc(assets, func(md immich.AssetMetadata) bool {
+		return md.ID == st.AssetID
+	}) + 1
+}
+
 // Next implements PlanIter and retrieves the next AssetMetadata.
 func (s *Sequential) Next() *immich.AssetMetadata {
 	if len(s.albums) == 0 {
@@ -70,6 +146,20 @@ func (s *Sequential) Next() *immich.AssetMetadata {
 	return &md
 }
 
+// Status implements StatusPlanIter and reports the position in the current
+// album.
+func (s *Sequential) Status() Status {
+	status := Status{
+		Name:     s.Name(),
+		Position: s.assetIndex,
+		Total:    len(s.assets),
+	}
+	if s.albumIndex >= 0 && s.albumIndex < len(s.albums) {
+		status.Album = s.albums[s.albumIndex].Name
+	}
+	return status
+}
+
 // getAlbumAssetsInOrder is a helper method to get the album asset metadata in
 // the order it is configured in immich (e.g. "asc" or "desc").
 func (s *Sequential) getAlbumAssetsInOrder(album immich.Album) ([]immich.AssetMetadata, error) {
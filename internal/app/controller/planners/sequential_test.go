@@ -3,6 +3,7 @@ package planners_test
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"immich-photo-frame/internal/app/controller/planners"
 	"immich-photo-frame/internal/immich"
@@ -12,7 +13,8 @@ var _ planners.AssetClient = testAssetClient{}
 
 // testAssetClient is a test implementation of [planners.AssetClient].
 type testAssetClient struct {
-	lut map[immich.AlbumID][]immich.AssetMetadata
+	lut      map[immich.AlbumID][]immich.AssetMetadata
+	memories []immich.Memory
 }
 
 // GetAlbumAssets implements planners.AssetClient.
@@ -27,6 +29,23 @@ func (t testAssetClient) GetAlbumAssets(id immich.AlbumID) ([]immich.AssetMetada
 	return c, nil
 }
 
+// GetAssetMetadata implements planners.AssetClient.
+func (t testAssetClient) GetAssetMetadata(id immich.AssetID) (*immich.AssetMetadata, error) {
+	for _, ass := range t.lut {
+		for _, md := range ass {
+			if md.ID == id {
+				return &md, nil
+			}
+		}
+	}
+	return nil, errors.New("not found")
+}
+
+// GetMemories implements planners.AssetClient.
+func (t testAssetClient) GetMemories(time.Time) ([]immich.Memory, error) {
+	return t.memories, nil
+}
+
 // TestSequentialPlanner tests SequentialPlanner iterates over configured
 // albums and assets in the order received.
 func TestSequentialPlanner(t *testing.T) {
@@ -131,3 +150,122 @@ func TestSequentialAsc(t *testing.T) {
 		}
 	}
 }
+
+// TestSequentialUpdate tests Sequential continues from the last returned asset
+// when albums are updated.
+func TestSequentialUpdate(t *testing.T) {
+	var seq planners.Sequential
+	client := testAssetClient{
+		lut: map[immich.AlbumID][]immich.AssetMetadata{
+			"album-1": {
+				{ID: "asset-1"},
+				{ID: "asset-2"},
+				{ID: "asset-3"},
+			},
+			"album-2": {
+				{ID: "asset-4"},
+			},
+		},
+	}
+
+	seq.Init(client, []immich.Album{{ID: "album-1"}})
+	for range 2 {
+		seq.Next()
+	}
+
+	// Add an asset after asset-2 and a new album.
+	client.lut["album-1"] = []immich.AssetMetadata{
+		{ID: "asset-1"},
+		{ID: "asset-2"},
+		{ID: "asset-5"},
+		{ID: "asset-3"},
+	}
+	seq.Update(client, []immich.Album{{ID: "album-1"}, {ID: "album-2"}})
+
+	var gotIDs []immich.AssetID
+	for range 4 {
+		ass := seq.Next()
+		if ass != nil {
+			gotIDs = append(gotIDs, ass.ID)
+		}
+	}
+	expectedIDs := []immich.AssetID{
+		"asset-5", "asset-3",
+		"asset-4",
+		"asset-1",
+	}
+	if len(gotIDs) != len(expectedIDs) {
+		t.Fatalf("expected %d items, found %d", len(expectedIDs), len(gotIDs))
+	}
+	for i := range expectedIDs {
+		if gotIDs[i] != expectedIDs[i] {
+			t.Fatalf(`gotIDs[%d] should be %q, found %q`, i, expectedIDs[i], gotIDs[i])
+		}
+	}
+}
+
+// TestSequentialUpdateRemovedAlbum tests Sequential continues with the next
+// album when the current album is removed.
+func TestSequentialUpdateRemovedAlbum(t *testing.T) {
+	var seq planners.Sequential
+	client := testAssetClient{
+		lut: map[immich.AlbumID][]immich.AssetMetadata{
+			"album-1": {{ID: "asset-1"}},
+			"album-2": {{ID: "asset-2"}, {ID: "asset-3"}},
+			"album-3": {{ID: "asset-4"}},
+		},
+	}
+
+	seq.Init(client, []immich.Album{{ID: "album-1"}, {ID: "album-2"}, {ID: "album-3"}})
+	for range 2 {
+		seq.Next()
+	}
+	seq.Update(client, []immich.Album{{ID: "album-1"}, {ID: "album-3"}})
+
+	if ass := seq.Next(); ass == nil || ass.ID != "asset-4" {
+		t.Fatalf(`expected "asset-4", found %v`, ass)
+	}
+}
+
+// TestSequentialRestoreState tests Sequential continues from the saved asset
+// after a restart.
+func TestSequentialRestoreState(t *testing.T) {
+	var seq planners.Sequential
+	client := testAssetClient{
+		lut: map[immich.AlbumID][]immich.AssetMetadata{
+			"album-1": {
+				{ID: "asset-1"},
+				{ID: "asset-2"},
+			},
+			"album-2": {
+				{ID: "asset-3"},
+				{ID: "asset-4"},
+			},
+		},
+	}
+	albums := []immich.Album{{ID: "album-1"}, {ID: "album-2"}}
+
+	seq.Init(client, albums)
+	for range 3 {
+		seq.Next()
+	}
+	state, err := seq.SaveState()
+	if err != nil {
+		t.Fatalf("failed to save state: %v", err)
+	}
+
+	var restored planners.Sequential
+	if err := restored.RestoreState(client, albums, state); err != nil {
+		t.Fatalf("failed to restore state: %v", err)
+	}
+	var gotIDs []immich.AssetID
+	for range 2 {
+		gotIDs = append(gotIDs, restored.Next().ID)
+	}
+	expectedIDs := []immich.AssetID{"asset-4", "asset-1"}
+	for i := range expectedIDs {
+		if gotIDs[i] != expectedIDs[i] {
+			t.Fatalf(`gotIDs[%d] should be %q, found %q`, i, expectedIDs[i], gotIDs[i])
+		}
+	}
+}
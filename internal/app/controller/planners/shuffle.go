@@ -1,6 +1,7 @@
 package planners
 
 import (
+	"encoding/json"
 	"log/slog"
 	"math/rand/v2"
 
@@ -9,7 +10,8 @@ import (
 
 // Shuffle implements PlanIter by shuffling the albums and their assets. It
 // works by saving all of the asset metadata in memory and shuffling, so this
-// may not be a good approach for a large amount of assets.
+// may not be a good approach for a large amount of assets. See
+// [StreamingShuffle] for large libraries.
 type Shuffle struct {
 	assets     []immich.AssetMetadata
 	assetIndex int
@@ -19,24 +21,60 @@ func (s *Shuffle) Name() string { return "shuffle" }
 
 // Init implements PlanIter and initializes the Shuffle object.
 func (s *Shuffle) Init(source AssetClient, albums []immich.Album) {
-	// Get all assets from all albums.
-	var assets []immich.AssetMetadata
-	for _, album := range albums {
-		ass, err := source.GetAlbumAssets(album.ID)
-		if err != nil {
-			slog.Error("failed to get album assets to shuffle",
-				"id", album.ID,
-				"name", album.Name,
-				"error", err,
-			)
-			continue
-		}
-		assets = append(assets, ass...)
-	}
-	*s = Shuffle{assets: assets}
+	*s = Shuffle{assets: getAllAlbumAssets(source, albums)}
 	s.shuffle()
 }
 
+// Update implements UpdatablePlanIter. Assets that were already shown in the
+// current round stay shown, removed assets are dropped, and new assets are
+// shuffled in with the assets that have not been shown yet.
+func (s *Shuffle) Update(source AssetClient, albums []immich.Album) {
+	s.restore(source, albums, s.state())
+}
+
+// shuffleState is the saved state of a Shuffle.
+type shuffleState struct {
+	Order []immich.AssetID `json:"order"`
+	Index int              `json:"index"`
+}
+
+// SaveState implements StatefulPlanIter and saves the shuffled order and the
+// position in it.
+func (s *Shuffle) SaveState() ([]byte, error) {
+	return json.Marshal(s.state())
+}
+
+// RestoreState implements StatefulPlanIter and continues the saved round,
+// reconciling it the same way as [Shuffle.Update].
+func (s *Shuffle) RestoreState(source AssetClient, albums []immich.Album, state []byte) error {
+	var st shuffleState
+	if err := json.Unmarshal(state, &st); err != nil {
+		return err
+	}
+	s.restore(source, albums, st)
+	return nil
+}
+
+// state is a helper method to get the current shuffleState.
+func (s *Shuffle) state() shuffleState {
+	order := make([]immich.AssetID, len(s.assets))
+	for i, md := range s.assets {
+		order[i] = md.ID
+	}
+	return shuffleState{Order: order, Index: s.assetIndex}
+}
+
+// restore is a helper method to rebuild the shuffle from the albums' assets,
+// continuing the round in st. See [reconcileShuffle].
+func (s *Shuffle) restore(source AssetClient, albums []immich.Album, st shuffleState) {
+	shown, unshown := reconcileShuffle(st, getAllAlbumAssets(source, albums),
+		func(md immich.AssetMetadata) immich.AssetID { return md.ID })
+	*s = Shuffle{
+		assets:     append(shown, unshown...),
+		assetIndex: len(shown),
+	}
+}
+
 // Next implements PlanIter and retrieves the next AssetMetadata.
 func (s *Shuffle) Next() *immich.AssetMetadata {
 	if len(s.assets) == 0 {
@@ -51,9 +89,75 @@ func (s *Shuffle) Next() *immich.AssetMetadata {
 	return &md
 }
 
+// Status implements StatusPlanIter and reports the position in the current
+// round.
+func (s *Shuffle) Status() Status {
+	return Status{
+		Name:     s.Name(),
+		Position: s.assetIndex,
+		Total:    len(s.assets),
+	}
+}
+
 // shuffle is a helper method to shuffle the contents of the assets slice.
 func (s *Shuffle) shuffle() {
 	rand.Shuffle(len(s.assets), func(i, j int) {
 		s.assets[i], s.assets[j] = s.assets[j], s.assets[i]
 	})
 }
+
+// reconcileShuffle is a helper function to continue the round in st with the
+// current assets. Assets that were shown stay shown, removed assets are
+// dropped, and new assets are inserted at random positions among the assets
+// that have not been shown yet. Duplicate assets are only included once.
+func reconcileShuffle[T any](st shuffleState, assets []T, id func(T) immich.AssetID) (shown, unshown []T) {
+	assetsByID := make(map[immich.AssetID]T, len(assets))
+	for _, a := range assets {
+		assetsByID[id(a)] = a
+	}
+
+	// Keep the assets that still exist, in their shuffled order.
+	for i, assetID := range st.Order {
+		a, ok := assetsByID[assetID]
+		if !ok {
+			continue
+		}
+		delete(assetsByID, assetID)
+		if i < st.Index {
+			shown = append(shown, a)
+		} else {
+			unshown = append(unshown, a)
+		}
+	}
+	// Everything else is new, so swap each one into a random position of
+	// the unshown assets.
+	for _, a := range assets {
+		if _, ok := assetsByID[id(a)]; !ok {
+			continue
+		}
+		delete(assetsByID, id(a))
+		unshown = append(unshown, a)
+		i, last := rand.IntN(len(unshown)), len(unshown)-1
+		unshown[i], unshown[last] = unshown[last], unshown[i]
+	}
+	return shown, unshown
+}
+
+// getAllAlbumAssets is a helper function to get the assets from all albums,
+// logging and skipping any albums that fail.
+func getAllAlbumAssets(source AssetClient, albums []immich.Album) []immich.AssetMetadata {
+	var assets []immich.AssetMetadata
+	for _, album := range albums {
+		ass, err := source.GetAlbumAssets(album.ID)
+		if err != nil {
+			slog.Error("failed to get album assets",
+				"id", album.ID,
+				"name", album.Name,
+				"error", err,
+			)
+			continue
+		}
+		assets = append(assets, ass...)
+	}
+	return assets
+}
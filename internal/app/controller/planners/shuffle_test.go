@@ -0,0 +1,110 @@
+package planners_test
+
+import (
+	"testing"
+
+	"immich-photo-frame/internal/app/controller/planners"
+	"immich-photo-frame/internal/immich"
+)
+
+// TestShuffleUpdate tests Shuffle does not repeat shown assets in the current
+// round after albums are updated, and includes new assets.
+func TestShuffleUpdate(t *testing.T) {
+	var shuffle planners.Shuffle
+	client := testAssetClient{
+		lut: map[immich.AlbumID][]immich.AssetMetadata{
+			"album-1": {
+				{ID: "asset-1"},
+				{ID: "asset-2"},
+				{ID: "asset-3"},
+				{ID: "asset-4"},
+			},
+		},
+	}
+
+	shuffle.Init(client, []immich.Album{{ID: "album-1"}})
+	seen := make(map[immich.AssetID]bool)
+	for range 2 {
+		seen[shuffle.Next().ID] = true
+	}
+
+	// Remove asset-1 and add asset-5.
+	client.lut["album-1"] = []immich.AssetMetadata{
+		{ID: "asset-2"},
+		{ID: "asset-3"},
+		{ID: "asset-4"},
+		{ID: "asset-5"},
+	}
+	shuffle.Update(client, []immich.Album{{ID: "album-1"}})
+
+	// The rest of the round is every remaining asset that was not shown.
+	var expected []immich.AssetID
+	for _, md := range client.lut["album-1"] {
+		if !seen[md.ID] {
+			expected = append(expected, md.ID)
+		}
+	}
+	got := make(map[immich.AssetID]bool)
+	for range expected {
+		md := shuffle.Next()
+		if seen[md.ID] {
+			t.Fatalf("%q was repeated in the same round", md.ID)
+		}
+		if md.ID == "asset-1" {
+			t.Fatal(`removed "asset-1" was returned`)
+		}
+		got[md.ID] = true
+	}
+	for _, id := range expected {
+		if !got[id] {
+			t.Fatalf("expected %q to be shown", id)
+		}
+	}
+}
+
+// TestShuffleRestoreState tests Shuffle continues its saved round after a
+// restart without repeating shown assets, even if assets were added or
+// removed.
+func TestShuffleRestoreState(t *testing.T) {
+	var shuffle planners.Shuffle
+	client := testAssetClient{
+		lut: map[immich.AlbumID][]immich.AssetMetadata{
+			"album-1": {
+				{ID: "asset-1"},
+				{ID: "asset-2"},
+				{ID: "asset-3"},
+				{ID: "asset-4"},
+			},
+		},
+	}
+	shuffle.Init(client, []immich.Album{{ID: "album-1"}})
+	seen := make(map[immich.AssetID]bool)
+	for range 3 {
+		seen[shuffle.Next().ID] = true
+	}
+	state, err := shuffle.SaveState()
+	if err != nil {
+		t.Fatalf("failed to save state: %v", err)
+	}
+
+	// Restore with an added asset on a new Shuffle.
+	client.lut["album-1"] = append(client.lut["album-1"], immich.AssetMetadata{ID: "asset-5"})
+	var restored planners.Shuffle
+	if err := restored.RestoreState(client, []immich.Album{{ID: "album-1"}}, state); err != nil {
+		t.Fatalf("failed to restore state: %v", err)
+	}
+	if status := restored.Status(); status.Position != 3 || status.Total != 5 {
+		t.Fatalf("expected position 3 of 5, got %+v", status)
+	}
+	got := make(map[immich.AssetID]bool)
+	for range 2 {
+		md := restored.Next()
+		if seen[md.ID] {
+			t.Fatalf("%q was repeated after restoring", md.ID)
+		}
+		got[md.ID] = true
+	}
+	if !got["asset-5"] {
+		t.Fatal(`expected new "asset-5" to be shown in the restored round`)
+	}
+}
@@ -0,0 +1,145 @@
+package planners
+
+import (
+	"encoding/json"
+	"log/slog"
+	"math/rand/v2"
+
+	"immich-photo-frame/internal/immich"
+)
+
+// StreamingShuffle implements PlanIter by shuffling the albums and their
+// assets like [Shuffle], but only keeps the asset IDs in memory. The metadata
+// of each asset is retrieved when it is returned by Next, which makes it
+// suitable for very large libraries.
+type StreamingShuffle struct {
+	source  AssetClient
+	ids     []immich.AssetID
+	idIndex int
+}
+
+func (s *StreamingShuffle) Name() string { return "streaming-shuffle" }
+
+// Init implements PlanIter and initializes the StreamingShuffle object.
+func (s *StreamingShuffle) Init(source AssetClient, albums []immich.Album) {
+	s.restore(source, albums, shuffleState{})
+}
+
+// Update implements UpdatablePlanIter, see [Shuffle.Update].
+func (s *StreamingShuffle) Update(source AssetClient, albums []immich.Album) {
+	s.restore(source, albums, s.state())
+}
+
+// Next implements PlanIter and retrieves the metadata of the next asset. If it
+// cannot be retrieved, the asset is skipped and nil is returned.
+func (s *StreamingShuffle) Next() *immich.AssetMetadata {
+	return s.NextDeferred()()
+}
+
+// NextDeferred implements DeferredPlanIter and advances to the next asset,
+// returning a function to retrieve its metadata.
+func (s *StreamingShuffle) NextDeferred() func() *immich.AssetMetadata {
+	if len(s.ids) == 0 {
+		return func() *immich.AssetMetadata { return nil }
+	}
+	if s.idIndex >= len(s.ids) {
+		s.idIndex = 0
+		s.shuffle()
+	}
+	id := s.ids[s.idIndex]
+	s.idIndex++
+	source := s.source
+	return func() *immich.AssetMetadata {
+		md, err := source.GetAssetMetadata(id)
+		if err != nil {
+			slog.Error("failed to get asset metadata", "id", id, "error", err)
+			return nil
+		}
+		return md
+	}
+}
+
+// UncachedAlbumAssets implements UncachedPlanIter. Only the asset IDs are
+// kept, so the album assets are not kept in the in-memory cache either.
+func (s *StreamingShuffle) UncachedAlbumAssets() bool { return true }
+
+// Status implements StatusPlanIter and reports the position in the current
+// round.
+func (s *StreamingShuffle) Status() Status {
+	return Status{
+		Name:     s.Name(),
+		Position: s.idIndex,
+		Total:    len(s.ids),
+	}
+}
+
+// SaveState implements StatefulPlanIter, see [Shuffle.SaveState].
+func (s *StreamingShuffle) SaveState() ([]byte, error) {
+	return json.Marshal(s.state())
+}
+
+// RestoreState implements StatefulPlanIter, see [Shuffle.RestoreState].
+func (s *StreamingShuffle) RestoreState(source AssetClient, albums []immich.Album, state []byte) error {
+	var st shuffleState
+	if err := json.Unmarshal(state, &st); err != nil {
+		return err
+	}
+	s.restore(source, albums, st)
+	return nil
+}
+
+// state is a helper method to get the current shuffleState.
+func (s *StreamingShuffle) state() shuffleState {
+	return shuffleState{Order: s.ids, Index: s.idIndex}
+}
+
+// restore is a helper method to rebuild the shuffle from the albums' asset
+// IDs, continuing the round in st. See [reconcileShuffle].
+func (s *StreamingShuffle) restore(source AssetClient, albums []immich.Album, st shuffleState) {
+	shown, unshown := reconcileShuffle(st, getAllAlbumAssetIDs(source, albums),
+		func(id immich.AssetID) immich.AssetID { return id })
+	*s = StreamingShuffle{
+		source:  source,
+		ids:     append(shown, unshown...),
+		idIndex: len(shown),
+	}
+}
+
+// shuffle is a helper method to shuffle the contents of the ids slice.
+func (s *StreamingShuffle) shuffle() {
+	rand.Shuffle(len(s.ids), func(i, j int) {
+		s.ids[i], s.ids[j] = s.ids[j], s.ids[i]
+	})
+}
+
+// getAllAlbumAssetIDs is a helper function to get the asset IDs from all
+// albums, logging and skipping any albums that fail. Each album's metadata is
+// retrieved without keeping it in a cache and dropped as soon as its IDs are
+// collected.
+func getAllAlbumAssetIDs(source AssetClient, albums []immich.Album) []immich.AssetID {
+	var ids []immich.AssetID
+	for _, album := range albums {
+		mds, err := getAlbumAssetsUncached(source, album.ID)
+		if err != nil {
+			slog.Error("failed to get album assets",
+				"id", album.ID,
+				"name", album.Name,
+				"error", err,
+			)
+			continue
+		}
+		for _, md := range mds {
+			ids = append(ids, md.ID)
+		}
+	}
+	return ids
+}
+
+// getAlbumAssetsUncached is a helper function to get the album's assets with
+// UncachedAssetClient if the source implements it, or GetAlbumAssets if not.
+func getAlbumAssetsUncached(source AssetClient, id immich.AlbumID) ([]immich.AssetMetadata, error) {
+	if client, ok := source.(UncachedAssetClient); ok {
+		return client.GetAlbumAssetsUncached(id)
+	}
+	return source.GetAlbumAssets(id)
+}
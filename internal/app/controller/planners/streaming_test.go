@@ -0,0 +1,207 @@
+package planners_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+
+	"immich-photo-frame/internal/app/controller/planners"
+	"immich-photo-frame/internal/immich"
+	"immich-photo-frame/internal/immich/api"
+)
+
+// TestStreamingShuffle tests StreamingShuffle shows every asset once per round
+// with its full metadata.
+func TestStreamingShuffle(t *testing.T) {
+	var shuffle planners.StreamingShuffle
+	client := testAssetClient{
+		lut: map[immich.AlbumID][]immich.AssetMetadata{
+			"album-1": {
+				{ID: "asset-1", Name: "one.jpg"},
+				{ID: "asset-2", Name: "two.jpg"},
+			},
+			"album-2": {
+				{ID: "asset-2", Name: "two.jpg"},
+				{ID: "asset-3", Name: "three.jpg"},
+			},
+		},
+	}
+	shuffle.Init(client, []immich.Album{{ID: "album-1"}, {ID: "album-2"}})
+
+	for round := range 2 {
+		seen := make(map[immich.AssetID]bool)
+		for range 3 {
+			md := shuffle.Next()
+			if seen[md.ID] {
+				t.Fatalf("round %d: %q was repeated", round, md.ID)
+			}
+			if md.Name == "" {
+				t.Fatalf("round %d: expected full metadata for %q", round, md.ID)
+			}
+			seen[md.ID] = true
+		}
+	}
+}
+
+// TestStreamingShuffleNextDeferred tests StreamingShuffle advances before the
+// asset metadata is looked up.
+func TestStreamingShuffleNextDeferred(t *testing.T) {
+	var shuffle planners.StreamingShuffle
+	client := testAssetClient{
+		lut: map[immich.AlbumID][]immich.AssetMetadata{
+			"album-1": {{ID: "asset-1", Name: "one.jpg"}},
+		},
+	}
+	shuffle.Init(client, []immich.Album{{ID: "album-1"}})
+
+	next := shuffle.NextDeferred()
+	if status := shuffle.Status(); status.Position != 1 {
+		t.Fatalf("expected position 1 before the lookup, got %+v", status)
+	}
+	if md := next(); md == nil || md.Name != "one.jpg" {
+		t.Fatalf("expected full metadata for %q, got %+v", "asset-1", md)
+	}
+}
+
+// uncachedAssetClient is a testAssetClient that records which albums were
+// retrieved without a cache.
+type uncachedAssetClient struct {
+	testAssetClient
+	uncached map[immich.AlbumID]bool
+}
+
+// GetAlbumAssetsUncached implements planners.UncachedAssetClient.
+func (u uncachedAssetClient) GetAlbumAssetsUncached(id immich.AlbumID) ([]immich.AssetMetadata, error) {
+	u.uncached[id] = true
+	return u.GetAlbumAssets(id)
+}
+
+// TestStreamingShuffleUncached tests StreamingShuffle retrieves album assets
+// without a cache through a Pipeline's filters.
+func TestStreamingShuffleUncached(t *testing.T) {
+	pipeline := planners.NewPipeline(new(planners.StreamingShuffle))
+	if !pipeline.UncachedAlbumAssets() {
+		t.Fatal("expected the pipeline to retrieve album assets without a cache")
+	}
+	client := uncachedAssetClient{
+		testAssetClient: testAssetClient{
+			lut: map[immich.AlbumID][]immich.AssetMetadata{
+				"album-1": {
+					{ID: "asset-1", Type: "IMAGE"},
+					{ID: "asset-2", Type: "VIDEO"},
+				},
+			},
+		},
+		uncached: make(map[immich.AlbumID]bool),
+	}
+	pipeline.Init(client, []immich.Album{{ID: "album-1"}})
+	if !client.uncached["album-1"] {
+		t.Error("expected album-1 to be retrieved without a cache")
+	}
+	if status := pipeline.Status(); status.Total != 1 {
+		t.Errorf("expected 1 asset after filtering, got %+v", status)
+	}
+	if planners.NewPipeline(new(planners.Shuffle)).UncachedAlbumAssets() {
+		t.Error("expected shuffle to retrieve album assets with the cache")
+	}
+}
+
+// benchmarkAssetClient is a helper function to create an AssetClient with a
+// large library of assets with realistic metadata.
+func benchmarkAssetClient(n int) testAssetClient {
+	assets := make([]immich.AssetMetadata, n)
+	for i := range assets {
+		assets[i] = immich.AssetMetadata{
+			ID:   immich.AssetID(fmt.Sprintf("00000000-0000-4000-8000-%012d", i)),
+			Type: "IMAGE",
+			Name: fmt.Sprintf("IMG_%05d.jpg", i),
+			ExifInfo: immich.ExifInfo{
+				City:             "Springfield",
+				State:            "Illinois",
+				Country:          "United States of America",
+				DateTimeOriginal: "2021-06-01T12:00:00.000Z",
+				TimeZone:         "America/Chicago",
+			},
+			People: []immich.Person{
+				{ID: immich.PersonID(fmt.Sprint(i)), Name: "Person", Faces: []immich.Face{{BoundingBoxX1: 1}}},
+			},
+		}
+	}
+	return testAssetClient{lut: map[immich.AlbumID][]immich.AssetMetadata{"album-1": assets}}
+}
+
+// benchmarkLibrarySize is the number of assets in the benchmark library.
+const benchmarkLibrarySize = 80_000
+
+// benchmarkRetained is a helper function to benchmark initializing the
+// PlanIter with a large library, reporting how much heap it retains.
+func benchmarkRetained(b *testing.B, newPlanIter func() planners.PlanIter) {
+	client := benchmarkAssetClient(benchmarkLibrarySize)
+	benchmarkClientRetained(b, newPlanIter, func() planners.AssetClient { return client })
+}
+
+// benchmarkClientRetained is a helper function to benchmark initializing the
+// PlanIter with the AssetClient from newClient, reporting how much heap both
+// retain.
+func benchmarkClientRetained(b *testing.B, newPlanIter func() planners.PlanIter, newClient func() planners.AssetClient) {
+	albums := []immich.Album{{ID: "album-1"}}
+	b.ReportAllocs()
+	var retained uint64
+	for b.Loop() {
+		var before, after runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+		client := newClient()
+		planIter := newPlanIter()
+		planIter.Init(client, albums)
+		// Collect twice so buffers pooled while decoding are dropped.
+		runtime.GC()
+		runtime.GC()
+		runtime.ReadMemStats(&after)
+		retained += after.HeapAlloc - min(before.HeapAlloc, after.HeapAlloc)
+		runtime.KeepAlive(planIter)
+		runtime.KeepAlive(client)
+	}
+	b.ReportMetric(float64(retained)/float64(b.N), "retained-B/op")
+}
+
+// benchmarkImmichClient is a helper function to benchmark initializing the
+// PlanIter through an immich.Client with an in-memory cache, fetching the
+// library from a fake immich server, so the cache is included in the heap it
+// retains.
+func benchmarkImmichClient(b *testing.B, newPlanIter func() planners.PlanIter) {
+	assets := benchmarkAssetClient(benchmarkLibrarySize).lut["album-1"]
+	body, err := json.Marshal(map[string]any{"assets": assets})
+	if err != nil {
+		b.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(body)
+	}))
+	b.Cleanup(srv.Close)
+	benchmarkClientRetained(b, newPlanIter, func() planners.AssetClient {
+		return immich.NewClient(
+			immich.WithRemote(api.Config{ImmichAPIEndpoint: srv.URL}),
+			immich.WithInMemoryCache(immich.InMemoryConfig{UseInMemoryCache: true, InMemoryCacheSize: 16 << 20}),
+		)
+	})
+}
+
+func BenchmarkShuffleInit(b *testing.B) {
+	benchmarkRetained(b, func() planners.PlanIter { return new(planners.Shuffle) })
+}
+
+func BenchmarkStreamingShuffleInit(b *testing.B) {
+	benchmarkRetained(b, func() planners.PlanIter { return new(planners.StreamingShuffle) })
+}
+
+func BenchmarkShuffleInitClient(b *testing.B) {
+	benchmarkImmichClient(b, func() planners.PlanIter { return new(planners.Shuffle) })
+}
+
+func BenchmarkStreamingShuffleInitClient(b *testing.B) {
+	benchmarkImmichClient(b, func() planners.PlanIter { return new(planners.StreamingShuffle) })
+}
@@ -0,0 +1,152 @@
+package planners
+
+import (
+	"encoding/json"
+	"log/slog"
+	"math/rand/v2"
+	"slices"
+
+	"immich-photo-frame/internal/immich"
+)
+
+// Weighted implements PlanIter by first drawing an album at random according
+// to its configured weight, then drawing the next asset of that album's
+// shuffle. Each album is shuffled separately, so an album does not repeat an
+// asset until all of its assets have been shown. Albums without a configured
+// weight have a weight of 1, and albums with a weight of 0 or less are never
+// shown.
+type Weighted struct {
+	weights map[string]float64
+	albums  []*weightedAlbum
+	// current is the album of the last asset returned by Next.
+	current *weightedAlbum
+}
+
+// weightedAlbum is an album and its shuffled assets.
+type weightedAlbum struct {
+	album   immich.Album
+	weight  float64
+	shuffle Shuffle
+}
+
+func (w *Weighted) Name() string { return "weighted" }
+
+// Configure implements ConfigurablePlanIter and sets the album weights.
+func (w *Weighted) Configure(opts Options) {
+	w.weights = opts.AlbumWeights
+}
+
+// Init implements PlanIter and initializes the Weighted object.
+func (w *Weighted) Init(source AssetClient, albums []immich.Album) {
+	*w = Weighted{weights: w.weights}
+	w.Update(source, albums)
+}
+
+// Update implements UpdatablePlanIter. Albums that are still configured keep
+// their position in their shuffle, see [Shuffle.Update].
+func (w *Weighted) Update(source AssetClient, albums []immich.Album) {
+	existing := make(map[immich.AlbumID]*weightedAlbum, len(w.albums))
+	for _, wa := range w.albums {
+		existing[wa.album.ID] = wa
+	}
+	w.albums = nil
+	for _, album := range albums {
+		wa, ok := existing[album.ID]
+		if ok {
+			wa.album = album
+			wa.shuffle.Update(source, []immich.Album{album})
+		} else {
+			wa = &weightedAlbum{album: album}
+			wa.shuffle.Init(source, []immich.Album{album})
+		}
+		wa.weight = w.weight(album)
+		w.albums = append(w.albums, wa)
+	}
+	if !slices.Contains(w.albums, w.current) {
+		w.current = nil
+	}
+}
+
+// SaveState implements StatefulPlanIter and saves the shuffle of each album.
+func (w *Weighted) SaveState() ([]byte, error) {
+	st := make(map[immich.AlbumID]shuffleState, len(w.albums))
+	for _, wa := range w.albums {
+		st[wa.album.ID] = wa.shuffle.state()
+	}
+	return json.Marshal(st)
+}
+
+// RestoreState implements StatefulPlanIter and continues the saved shuffle of
+// each album, see [Shuffle.RestoreState]. Albums without saved state are
+// shuffled from scratch.
+func (w *Weighted) RestoreState(source AssetClient, albums []immich.Album, state []byte) error {
+	var st map[immich.AlbumID]shuffleState
+	if err := json.Unmarshal(state, &st); err != nil {
+		return err
+	}
+	*w = Weighted{weights: w.weights}
+	for _, album := range albums {
+		wa := &weightedAlbum{album: album, weight: w.weight(album)}
+		if albumState, ok := st[album.ID]; ok {
+			wa.shuffle.restore(source, []immich.Album{album}, albumState)
+		} else {
+			wa.shuffle.Init(source, []immich.Album{album})
+		}
+		w.albums = append(w.albums, wa)
+	}
+	return nil
+}
+
+// Next implements PlanIter and retrieves the next AssetMetadata.
+func (w *Weighted) Next() *immich.AssetMetadata {
+	var total float64
+	for _, wa := range w.albums {
+		if wa.drawable() {
+			total += wa.weight
+		}
+	}
+	if total == 0 {
+		return nil
+	}
+	r := rand.Float64() * total
+	for _, wa := range w.albums {
+		if !wa.drawable() {
+			continue
+		}
+		w.current = wa
+		if r < wa.weight {
+			break
+		}
+		r -= wa.weight
+	}
+	return w.current.shuffle.Next()
+}
+
+// Status implements StatusPlanIter and reports the position in the shuffle of
+// the album that was last drawn.
+func (w *Weighted) Status() Status {
+	if w.current == nil {
+		return Status{Name: w.Name()}
+	}
+	status := w.current.shuffle.Status()
+	status.Name = w.Name()
+	status.Album = w.current.album.Name
+	return status
+}
+
+// weight is a helper method to get the configured weight of the album.
+func (w *Weighted) weight(album immich.Album) float64 {
+	weight, ok := w.weights[album.Name]
+	if !ok {
+		return 1
+	}
+	if weight <= 0 {
+		slog.Debug("album will not be shown", "name", album.Name, "weight", weight)
+	}
+	return weight
+}
+
+// drawable is a helper method to check if the album can be drawn.
+func (wa *weightedAlbum) drawable() bool {
+	return wa.weight > 0 && len(wa.shuffle.assets) > 0
+}
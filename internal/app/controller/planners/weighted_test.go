@@ -0,0 +1,63 @@
+package planners_test
+
+import (
+	"testing"
+
+	"immich-photo-frame/internal/app/controller/planners"
+	"immich-photo-frame/internal/immich"
+)
+
+// TestWeighted tests Weighted draws albums according to their weights and
+// does not repeat assets within an album until all have been shown.
+func TestWeighted(t *testing.T) {
+	var weighted planners.Weighted
+	client := testAssetClient{
+		lut: map[immich.AlbumID][]immich.AssetMetadata{
+			"album-1": {
+				{ID: "asset-1"},
+				{ID: "asset-2"},
+				{ID: "asset-3"},
+			},
+			"album-2": {
+				{ID: "asset-4"},
+			},
+			"album-3": {
+				{ID: "asset-5"},
+			},
+		},
+	}
+	albums := []immich.Album{
+		{ID: "album-1", Name: "Family"},
+		{ID: "album-2", Name: "Grandparents"},
+		{ID: "album-3", Name: "Hidden"},
+	}
+	weighted.Configure(planners.Options{
+		AlbumWeights: map[string]float64{"Grandparents": 3, "Hidden": 0},
+	})
+	weighted.Init(client, albums)
+
+	const draws = 4000
+	counts := make(map[immich.AssetID]int)
+	var family []immich.AssetID
+	for range draws {
+		md := weighted.Next()
+		counts[md.ID]++
+		if md.ID != "asset-4" {
+			family = append(family, md.ID)
+		}
+	}
+	if counts["asset-5"] != 0 {
+		t.Fatalf(`"asset-5" has weight 0 but was shown %d times`, counts["asset-5"])
+	}
+	// Grandparents should be drawn 3 out of every 4 times.
+	if ratio := float64(counts["asset-4"]) / draws; ratio < 0.7 || ratio > 0.8 {
+		t.Fatalf(`expected "asset-4" to be drawn about 75%% of the time, got %.2f`, ratio)
+	}
+	// Every round of the Family album shows each asset once.
+	for i := 0; i+3 <= len(family); i += 3 {
+		round := map[immich.AssetID]bool{family[i]: true, family[i+1]: true, family[i+2]: true}
+		if len(round) != 3 {
+			t.Fatalf("album round %v repeated an asset", family[i:i+3])
+		}
+	}
+}
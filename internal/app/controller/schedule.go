@@ -0,0 +1,115 @@
+package controller
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"immich-photo-frame/internal/app/controller/planners"
+)
+
+// Schedule holds configuration values for showing different albums with a
+// different PlanAlgorithm during a window of time. The window is between Start
+// and End on any of Days in any of Months, where an empty list matches every
+// day or month. Windows with an End before their Start span midnight, and
+// windows with the same Start and End last all day. An empty ImmichAlbums uses
+// all albums, and an unset PlanAlgorithm uses the same algorithm as the
+// Controller's Config.
+//
+// It is organized to take advantage of TOML parsing, however this package does
+// not handle parsing and has no expectation on how it will be initialized.
+type Schedule struct {
+	Name          string
+	Start         ClockTime
+	End           ClockTime
+	Days          []Weekday
+	Months        []Month
+	ImmichAlbums  []string
+	PlanAlgorithm planners.PlanAlgorithm
+}
+
+// Active reports whether t is within the Schedule's window. Windows spanning
+// midnight match Days and Months by the day t is on, not the day the window
+// started.
+func (s Schedule) Active(t time.Time) bool {
+	if len(s.Months) > 0 && !slices.Contains(s.Months, Month(t.Month())) {
+		return false
+	}
+	if len(s.Days) > 0 && !slices.Contains(s.Days, Weekday(t.Weekday())) {
+		return false
+	}
+	start, end, now := s.Start.minutes(), s.End.minutes(), t.Hour()*60+t.Minute()
+	switch {
+	case start == end:
+		return true
+	case start < end:
+		return start <= now && now < end
+	default:
+		return now >= start || now < end
+	}
+}
+
+// ClockTime is a time of day with minute precision, written as "HH:MM".
+type ClockTime struct {
+	Hour   int
+	Minute int
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (c *ClockTime) UnmarshalText(text []byte) error {
+	t, err := time.Parse("15:04", string(text))
+	if err != nil {
+		return fmt.Errorf("unsupported time of day %q, expected a format of %q", string(text), "HH:MM")
+	}
+	c.Hour, c.Minute = t.Hour(), t.Minute()
+	return nil
+}
+
+// minutes is a helper method to get the number of minutes since midnight.
+func (c ClockTime) minutes() int {
+	return c.Hour*60 + c.Minute
+}
+
+// Weekday is a time.Weekday that can be unmarshalled from its full or
+// abbreviated English name, such as "monday" or "mon".
+type Weekday time.Weekday
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (d *Weekday) UnmarshalText(text []byte) error {
+	var names []string
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		name := strings.ToLower(day.String())
+		if matchesName(string(text), name) {
+			*d = Weekday(day)
+			return nil
+		}
+		names = append(names, fmt.Sprintf("%q", name))
+	}
+	return fmt.Errorf("unsupported day %q, expected one of %v", string(text), names)
+}
+
+// Month is a time.Month that can be unmarshalled from its full or abbreviated
+// English name, such as "december" or "dec".
+type Month time.Month
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (m *Month) UnmarshalText(text []byte) error {
+	var names []string
+	for month := time.January; month <= time.December; month++ {
+		name := strings.ToLower(month.String())
+		if matchesName(string(text), name) {
+			*m = Month(month)
+			return nil
+		}
+		names = append(names, fmt.Sprintf("%q", name))
+	}
+	return fmt.Errorf("unsupported month %q, expected one of %v", string(text), names)
+}
+
+// matchesName is a helper function to check if text is a name or its three
+// letter abbreviation, ignoring case.
+func matchesName(text, name string) bool {
+	text = strings.ToLower(text)
+	return text == name || text == name[:3]
+}
@@ -0,0 +1,85 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+func TestScheduleActive(t *testing.T) {
+	// 2024-12-02 is a Monday.
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, time.December, day, hour, minute, 0, 0, time.Local)
+	}
+	for _, tc := range []struct {
+		name     string
+		schedule Schedule
+		t        time.Time
+		want     bool
+	}{
+		{"all day", Schedule{}, at(2, 3, 0), true},
+		{"before window", Schedule{Start: ClockTime{7, 0}, End: ClockTime{12, 0}}, at(2, 6, 59), false},
+		{"window start", Schedule{Start: ClockTime{7, 0}, End: ClockTime{12, 0}}, at(2, 7, 0), true},
+		{"window end", Schedule{Start: ClockTime{7, 0}, End: ClockTime{12, 0}}, at(2, 12, 0), false},
+		{"overnight evening", Schedule{Start: ClockTime{21, 0}, End: ClockTime{6, 0}}, at(2, 23, 30), true},
+		{"overnight morning", Schedule{Start: ClockTime{21, 0}, End: ClockTime{6, 0}}, at(3, 5, 59), true},
+		{"overnight day", Schedule{Start: ClockTime{21, 0}, End: ClockTime{6, 0}}, at(3, 12, 0), false},
+		{"matching day", Schedule{Days: []Weekday{Weekday(time.Monday)}}, at(2, 12, 0), true},
+		{"other day", Schedule{Days: []Weekday{Weekday(time.Monday)}}, at(3, 12, 0), false},
+		{"matching month", Schedule{Months: []Month{Month(time.December)}}, at(25, 12, 0), true},
+		{"other month", Schedule{Months: []Month{Month(time.January)}}, at(25, 12, 0), false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.schedule.Active(tc.t); got != tc.want {
+				t.Errorf("Active(%v) = %t, want %t", tc.t, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestScheduleDecode(t *testing.T) {
+	var conf struct{ Schedule []Schedule }
+	_, err := toml.Decode(`
+[[schedule]]
+name = "mornings"
+start = "07:00"
+end = "09:30"
+days = ["mon", "Tuesday"]
+immichAlbums = ["Kids"]
+planAlgorithm = "shuffle"
+
+[[schedule]]
+months = ["dec"]
+immichAlbums = ["Holidays"]
+`, &conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(conf.Schedule) != 2 {
+		t.Fatalf("expected 2 schedules, got %d", len(conf.Schedule))
+	}
+	mornings := conf.Schedule[0]
+	if mornings.Start != (ClockTime{7, 0}) || mornings.End != (ClockTime{9, 30}) {
+		t.Errorf("expected window 07:00 to 09:30, got %v to %v", mornings.Start, mornings.End)
+	}
+	if len(mornings.Days) != 2 || mornings.Days[0] != Weekday(time.Monday) || mornings.Days[1] != Weekday(time.Tuesday) {
+		t.Errorf("expected Monday and Tuesday, got %v", mornings.Days)
+	}
+	if mornings.PlanAlgorithm.Name() != "shuffle" {
+		t.Errorf("expected shuffle, got %q", mornings.PlanAlgorithm.Name())
+	}
+	holidays := conf.Schedule[1]
+	if len(holidays.Months) != 1 || holidays.Months[0] != Month(time.December) {
+		t.Errorf("expected December, got %v", holidays.Months)
+	}
+	if holidays.PlanAlgorithm.PlanIter != nil {
+		t.Errorf("expected no plan algorithm, got %q", holidays.PlanAlgorithm.Name())
+	}
+
+	for _, bad := range []string{`start = "7am"`, `days = ["someday"]`, `months = ["smarch"]`} {
+		if _, err := toml.Decode("[[schedule]]\n"+bad, &conf); err == nil {
+			t.Errorf("expected error decoding %s", bad)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package display
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"slices"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"github.com/disintegration/imaging"
+)
+
+// BackgroundFill is how the area around an image that does not match the
+// screen's aspect ratio is filled.
+type BackgroundFill string
+
+const (
+	BackgroundFillBlack         BackgroundFill = "black"
+	BackgroundFillBlur          BackgroundFill = "blur"
+	BackgroundFillDominantColor BackgroundFill = "dominant-color"
+	BackgroundFillMirror        BackgroundFill = "mirror"
+)
+
+// backgroundFills is the list of all the BackgroundFills that can be
+// configured.
+var backgroundFills = []BackgroundFill{
+	BackgroundFillBlack,
+	BackgroundFillBlur,
+	BackgroundFillDominantColor,
+	BackgroundFillMirror,
+}
+
+// UnmarshalText implements toml.TextUnmarshaler.
+func (b *BackgroundFill) UnmarshalText(text []byte) error {
+	name := BackgroundFill(strings.ToLower(string(text)))
+	if slices.Contains(backgroundFills, name) {
+		*b = name
+		return nil
+	}
+	var validFills []string
+	for _, fill := range backgroundFills {
+		validFills = append(validFills, fmt.Sprintf("%q", fill))
+	}
+	return fmt.Errorf(
+		"unsupported background fill %q, expected one of %v",
+		string(text), validFills,
+	)
+}
+
+// backgroundWidth is the width that backgrounds are computed at. They are
+// blurry or uniform, so there is no need for more detail, and it keeps the
+// work in the prefetch path cheap.
+const backgroundWidth = 160
+
+// background computes the image to draw behind img so it fills a view of the
+// provided size. It returns nil if the background should be left black.
+func (b BackgroundFill) background(img image.Image, view fyne.Size) image.Image {
+	switch b {
+	case BackgroundFillBlur:
+		small := imaging.Resize(img, backgroundWidth, 0, imaging.Box)
+		return imaging.AdjustBrightness(imaging.Blur(small, 4), -20)
+	case BackgroundFillDominantColor:
+		small := imaging.Resize(img, backgroundWidth, 0, imaging.Box)
+		bg := image.NewRGBA(image.Rect(0, 0, 1, 1))
+		bg.Set(0, 0, dominantColor(small))
+		return bg
+	case BackgroundFillMirror:
+		return mirrorBackground(imaging.Resize(img, backgroundWidth, 0, imaging.Box), view)
+	}
+	return nil
+}
+
+// dominantColor is a helper function to find the most common color in the
+// image. Colors are bucketed so similar shades count together, and the
+// average color of the largest bucket is returned.
+func dominantColor(img *image.NRGBA) color.Color {
+	type bucket struct {
+		n       int
+		r, g, b int
+	}
+	var buckets [16 * 16 * 16]bucket
+	var largest *bucket
+	for i := 0; i+3 < len(img.Pix); i += 4 {
+		r, g, b := int(img.Pix[i]), int(img.Pix[i+1]), int(img.Pix[i+2])
+		bk := &buckets[(r>>4)<<8|(g>>4)<<4|(b>>4)]
+		bk.n++
+		bk.r, bk.g, bk.b = bk.r+r, bk.g+g, bk.b+b
+		if largest == nil || bk.n > largest.n {
+			largest = bk
+		}
+	}
+	if largest == nil {
+		return color.Black
+	}
+	return color.NRGBA{
+		R: uint8(largest.r / largest.n),
+		G: uint8(largest.g / largest.n),
+		B: uint8(largest.b / largest.n),
+		A: 0xff,
+	}
+}
+
+// mirrorBackground is a helper function to place mirrored copies of the image
+// on either side of it, along the axis where the image does not fill the
+// view. The copies are darkened and blurred slightly so the real image stands
+// out. The background has the same aspect ratio as the view, so it is drawn
+// without cropping and the image in it lines up with the image in front.
+func mirrorBackground(img *image.NRGBA, view fyne.Size) image.Image {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	if w == 0 || h == 0 || view.Width <= 0 || view.Height <= 0 {
+		return nil
+	}
+	side := imaging.AdjustBrightness(imaging.Blur(img, 1), -30)
+	// Compare aspect ratios to determine if the image is narrower than
+	// the view (bars on the left and right) or shorter (bars on the top
+	// and bottom).
+	if float32(w)*view.Height < view.Width*float32(h) {
+		bg := image.NewNRGBA(image.Rect(0, 0, int(math.Round(float64(float32(h)*view.Width/view.Height))), h))
+		flipped := imaging.FlipH(side)
+		for _, tile := range mirrorTiles(bg.Bounds().Dx(), w) {
+			src := image.Image(img)
+			if tile.flipped {
+				src = flipped
+			} else if tile.distance > 0 {
+				src = side
+			}
+			draw.Draw(bg, image.Rect(tile.start, 0, tile.start+w, h), src, image.Point{}, draw.Src)
+		}
+		return bg
+	}
+	bg := image.NewNRGBA(image.Rect(0, 0, w, int(math.Round(float64(float32(w)*view.Height/view.Width)))))
+	flipped := imaging.FlipV(side)
+	for _, tile := range mirrorTiles(bg.Bounds().Dy(), h) {
+		src := image.Image(img)
+		if tile.flipped {
+			src = flipped
+		} else if tile.distance > 0 {
+			src = side
+		}
+		draw.Draw(bg, image.Rect(0, tile.start, w, tile.start+h), src, image.Point{}, draw.Src)
+	}
+	return bg
+}
+
+// mirrorTile is a copy of the image along one axis of a mirrored background.
+type mirrorTile struct {
+	// start is where the copy starts, which may be before the background.
+	start int
+	// distance is how many copies away from the image it is, where 0 is the
+	// image itself.
+	distance int
+	// flipped is set for every other copy, so the edges of neighboring
+	// copies match.
+	flipped bool
+}
+
+// mirrorTiles is a helper function to lay out copies of an image size pixels
+// long, centered along an axis length pixels long, with as many copies on
+// either side as it takes to cover it.
+func mirrorTiles(length, size int) []mirrorTile {
+	center := (length - size) / 2
+	n := (center + size - 1) / size
+	var tiles []mirrorTile
+	for i := -n; center+i*size < length; i++ {
+		d := max(i, -i)
+		tiles = append(tiles, mirrorTile{start: center + i*size, distance: d, flipped: d%2 == 1})
+	}
+	return tiles
+}
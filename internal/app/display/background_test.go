@@ -0,0 +1,137 @@
+package display
+
+import (
+	"image"
+	"image/color"
+	"slices"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"github.com/disintegration/imaging"
+)
+
+func TestBackgroundFillUnmarshalText(t *testing.T) {
+	for _, tt := range []struct {
+		text string
+		want BackgroundFill
+		ok   bool
+	}{
+		{"black", BackgroundFillBlack, true},
+		{"Blur", BackgroundFillBlur, true},
+		{"dominant-color", BackgroundFillDominantColor, true},
+		{"MIRROR", BackgroundFillMirror, true},
+		{"dominant", "", false},
+	} {
+		var got BackgroundFill
+		err := got.UnmarshalText([]byte(tt.text))
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("UnmarshalText(%q) = %q, %v, want %q, ok %t", tt.text, got, err, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestBackground(t *testing.T) {
+	img := imaging.New(400, 800, color.NRGBA{R: 200, A: 0xff})
+	view := fyne.NewSize(1600, 900)
+	for _, tt := range []struct {
+		fill BackgroundFill
+		size image.Point
+	}{
+		{BackgroundFillBlack, image.Point{}},
+		{BackgroundFillBlur, image.Pt(backgroundWidth, 2*backgroundWidth)},
+		{BackgroundFillDominantColor, image.Pt(1, 1)},
+		// Sized to the view's aspect ratio at the background width.
+		{BackgroundFillMirror, image.Pt(569, 2*backgroundWidth)},
+	} {
+		bg := tt.fill.background(img, view)
+		if tt.size == (image.Point{}) {
+			if bg != nil {
+				t.Errorf("%s: expected no background", tt.fill)
+			}
+			continue
+		}
+		if bg == nil || bg.Bounds().Size() != tt.size {
+			t.Errorf("%s: expected a %v background, got %v", tt.fill, tt.size, bg)
+		}
+	}
+}
+
+func TestDominantColor(t *testing.T) {
+	img := imaging.New(10, 10, color.NRGBA{R: 200, G: 10, B: 10, A: 0xff})
+	// Fewer pixels of a different color, and a slightly different shade
+	// of the main color that counts towards it.
+	for x := range 10 {
+		img.Set(x, 0, color.NRGBA{B: 200, A: 0xff})
+		img.Set(x, 1, color.NRGBA{B: 200, A: 0xff})
+		img.Set(x, 2, color.NRGBA{R: 202, G: 12, B: 12, A: 0xff})
+	}
+	want := color.NRGBA{R: 200, G: 10, B: 10, A: 0xff}
+	if got := dominantColor(img); got != want {
+		t.Errorf("dominantColor() = %v, want %v", got, want)
+	}
+	if got := dominantColor(image.NewNRGBA(image.Rectangle{})); got != color.Black {
+		t.Errorf("dominantColor() of an empty image = %v, want black", got)
+	}
+}
+
+func TestMirrorTiles(t *testing.T) {
+	for _, tt := range []struct {
+		length, size int
+		want         []mirrorTile
+	}{
+		{300, 100, []mirrorTile{{0, 1, true}, {100, 0, false}, {200, 1, true}}},
+		{250, 100, []mirrorTile{{-25, 1, true}, {75, 0, false}, {175, 1, true}}},
+		// A very tall portrait needs more than one copy on each side.
+		{560, 100, []mirrorTile{
+			{-70, 3, true}, {30, 2, false}, {130, 1, true},
+			{230, 0, false},
+			{330, 1, true}, {430, 2, false}, {530, 3, true},
+		}},
+		{100, 100, []mirrorTile{{0, 0, false}}},
+	} {
+		if got := mirrorTiles(tt.length, tt.size); !slices.Equal(got, tt.want) {
+			t.Errorf("mirrorTiles(%d, %d) = %v, want %v", tt.length, tt.size, got, tt.want)
+		}
+	}
+}
+
+func TestMirrorBackground(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		imgSize image.Point
+		view    fyne.Size
+		size    image.Point
+		// offset is where the image is in the background.
+		offset image.Point
+	}{
+		{"portrait", image.Pt(90, 160), fyne.NewSize(1600, 900), image.Pt(284, 160), image.Pt(97, 0)},
+		{"tall portrait", image.Pt(40, 160), fyne.NewSize(1600, 900), image.Pt(284, 160), image.Pt(122, 0)},
+		{"panorama", image.Pt(160, 20), fyne.NewSize(900, 1600), image.Pt(160, 284), image.Pt(0, 132)},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			img := image.NewNRGBA(image.Rectangle{Max: tt.imgSize})
+			for i := range img.Pix {
+				img.Pix[i] = uint8(i)
+			}
+			for i := 3; i < len(img.Pix); i += 4 {
+				img.Pix[i] = 0xff
+			}
+			bg, ok := mirrorBackground(img, tt.view).(*image.NRGBA)
+			if !ok || bg.Bounds().Size() != tt.size {
+				t.Fatalf("expected a %v background, got %v", tt.size, bg.Bounds())
+			}
+			for y := range tt.imgSize.Y {
+				for x := range tt.imgSize.X {
+					if got, want := bg.NRGBAAt(x+tt.offset.X, y+tt.offset.Y), img.NRGBAAt(x, y); got != want {
+						t.Fatalf("expected the image at %v, got %v at %v instead of %v", tt.offset, got, image.Pt(x, y), want)
+					}
+				}
+			}
+			for i := 3; i < len(bg.Pix); i += 4 {
+				if bg.Pix[i] != 0xff {
+					t.Fatal("expected the background to be covered by copies of the image")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package display
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"math"
+
+	"github.com/disintegration/imaging"
+
+	"immich-photo-frame/internal/immich"
+)
+
+// decodeForScreen is a helper function to decode the asset's image and, if it
+// is larger than the screen scaled by scale, quickly downscale it to fit. If
+// the screen size is unknown, the image is scaled by scale instead.
+func decodeForScreen(ass *immich.Asset, screen image.Point, scale float32) (image.Image, error) {
+	img, err := decodeImage(ass)
+	if err != nil {
+		return nil, err
+	}
+	size := img.Bounds().Size()
+	target := scalePoint(size, scale)
+	if screen != (image.Point{}) {
+		target = fitSize(size, scalePoint(screen, scale))
+	}
+	if target.X > 0 && target.Y > 0 && target.X < size.X && target.Y < size.Y {
+		// Box filtering averages the source pixels under each target
+		// pixel, which is much faster than Lanczos and looks the same
+		// when downscaling photos.
+		img = imaging.Resize(img, target.X, target.Y, imaging.Box)
+	}
+	return img, nil
+}
+
+// renditionSize is a helper function to get how many pixels long the shortest
+// side of an image needs to be to cover its area of the screen, scaled by
+// scale. Images with unknown dimensions are assumed to need the shortest side
+// of the screen, which covers any aspect ratio.
+func renditionSize(size, screen image.Point, scale float32) int {
+	if size.X > 0 && size.Y > 0 {
+		screen = fitSize(size, screen)
+	}
+	return int(math.Ceil(float64(float32(min(screen.X, screen.Y)) * scale)))
+}
+
+// fitSize is a helper function to get the largest size with the same aspect
+// ratio as size that fits within bounds.
+func fitSize(size, bounds image.Point) image.Point {
+	if size.X*bounds.Y > bounds.X*size.Y {
+		return image.Pt(bounds.X, max(1, size.Y*bounds.X/size.X))
+	}
+	return image.Pt(max(1, size.X*bounds.Y/size.Y), bounds.Y)
+}
+
+// scalePoint is a helper function to scale both coordinates of the point.
+func scalePoint(p image.Point, scale float32) image.Point {
+	return image.Pt(int(float32(p.X)*scale), int(float32(p.Y)*scale))
+}
+
+// decodeImage is a helper function to decode the asset's image, rotated to be
+// upright. Camera RAW files either have no Go decoder, or are TIFF files where
+// only a small thumbnail can be decoded, so they are decoded from the largest
+// JPEG preview embedded in them instead, if it is larger.
+func decodeImage(ass *immich.Asset) (image.Image, error) {
+	conf, format, err := image.DecodeConfig(bytes.NewReader(ass.Data))
+	if err != nil || format == "tiff" {
+		preview, previewConf := embeddedJPEG(ass.Data)
+		if preview != nil && previewConf.Width*previewConf.Height > conf.Width*conf.Height {
+			img, err := jpeg.Decode(bytes.NewReader(preview))
+			if err != nil {
+				return nil, err
+			}
+			// Embedded previews rely on the orientation of the RAW
+			// file, which immich reads for us.
+			return orient(img, ass.Meta.ExifInfo.Orientation), nil
+		}
+	}
+	return imaging.Decode(bytes.NewReader(ass.Data), imaging.AutoOrientation(true))
+}
+
+// embeddedJPEG is a helper function to find the largest JPEG embedded in the
+// data, such as the full size preview most cameras include in RAW files, and
+// its config. It returns nil if there is none.
+func embeddedJPEG(data []byte) ([]byte, image.Config) {
+	soi := []byte{0xFF, 0xD8, 0xFF}
+	var largest []byte
+	var largestConf image.Config
+	for i := 0; ; {
+		j := bytes.Index(data[i:], soi)
+		if j < 0 {
+			return largest, largestConf
+		}
+		start := i + j
+		conf, err := jpeg.DecodeConfig(bytes.NewReader(data[start:]))
+		if err == nil && conf.Width*conf.Height > largestConf.Width*largestConf.Height {
+			largest, largestConf = data[start:], conf
+		}
+		i = start + len(soi)
+	}
+}
+
+// orient is a helper function to rotate and flip the image according to its
+// EXIF orientation, so it is upright.
+func orient(img image.Image, orientation string) image.Image {
+	switch orientation {
+	case "2":
+		return imaging.FlipH(img)
+	case "3":
+		return imaging.Rotate180(img)
+	case "4":
+		return imaging.FlipV(img)
+	case "5":
+		return imaging.Transpose(img)
+	case "6":
+		return imaging.Rotate270(img)
+	case "7":
+		return imaging.Transverse(img)
+	case "8":
+		return imaging.Rotate90(img)
+	}
+	return img
+}
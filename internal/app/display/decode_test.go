@@ -0,0 +1,160 @@
+package display
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+
+	"github.com/disintegration/imaging"
+
+	"immich-photo-frame/internal/immich"
+)
+
+// testJPEG is a helper function to encode a blank JPEG of the given size.
+func testJPEG(t testing.TB, width, height int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, width, height)), nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecodeImageJPEG(t *testing.T) {
+	img, err := decodeImage(&immich.Asset{Data: testJPEG(t, 64, 32)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size := img.Bounds().Size(); size != image.Pt(64, 32) {
+		t.Errorf("expected 64x32, got %v", size)
+	}
+}
+
+func TestDecodeImageEmbeddedJPEG(t *testing.T) {
+	// Mimic a RAW file with a small thumbnail and a larger preview.
+	var raw bytes.Buffer
+	raw.WriteString("not a real raw header")
+	raw.Write(testJPEG(t, 16, 8))
+	raw.Write([]byte{0xFF, 0xD8, 0xFF, 0x00, 0x00})
+	raw.Write(testJPEG(t, 64, 32))
+	raw.WriteString("raw sensor data")
+
+	ass := &immich.Asset{
+		Meta: immich.AssetMetadata{ExifInfo: immich.ExifInfo{Orientation: "6"}},
+		Data: raw.Bytes(),
+	}
+	img, err := decodeImage(ass)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The preview is rotated according to the RAW file's orientation.
+	if size := img.Bounds().Size(); size != image.Pt(32, 64) {
+		t.Errorf("expected the rotated 32x64 preview, got %v", size)
+	}
+
+	if _, err := decodeImage(&immich.Asset{Data: []byte("no images here")}); err == nil {
+		t.Error("expected an error decoding data without an image")
+	}
+}
+
+func TestRenditionSize(t *testing.T) {
+	uhd := image.Pt(3840, 2160)
+	for _, tt := range []struct {
+		name     string
+		size     image.Point
+		screen   image.Point
+		scale    float32
+		expected int
+	}{
+		{"landscape fills height", image.Pt(4000, 3000), uhd, 1, 2160},
+		{"panorama fills width", image.Pt(8000, 2000), uhd, 1, 960},
+		{"portrait fills height", image.Pt(3000, 4000), uhd, 1, 1620},
+		{"unknown dimensions", image.Point{}, uhd, 1, 2160},
+		{"scaled", image.Pt(4000, 3000), uhd, 0.5, 1080},
+		{"portrait screen", image.Pt(4000, 3000), image.Pt(1080, 1920), 1, 810},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renditionSize(tt.size, tt.screen, tt.scale); got != tt.expected {
+				t.Errorf("expected %d, got %d", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestDecodeForScreen(t *testing.T) {
+	ass := &immich.Asset{Data: testJPEG(t, 400, 300)}
+	for _, tt := range []struct {
+		name     string
+		screen   image.Point
+		scale    float32
+		expected image.Point
+	}{
+		{"larger than screen", image.Pt(200, 200), 1, image.Pt(200, 150)},
+		{"smaller than screen", image.Pt(800, 600), 1, image.Pt(400, 300)},
+		{"scaled screen", image.Pt(400, 300), 0.5, image.Pt(200, 150)},
+		{"unknown screen", image.Point{}, 0.5, image.Pt(200, 150)},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := decodeForScreen(ass, tt.screen, tt.scale)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if size := img.Bounds().Size(); size != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, size)
+			}
+		})
+	}
+}
+
+// benchmarkDecode is a helper function to benchmark decoding a JPEG of the
+// given size for a 1080p screen, either the way assets used to be decoded or
+// with decodeForScreen.
+func benchmarkDecode(b *testing.B, size image.Point, screenSized bool) {
+	img := image.NewRGBA(image.Rect(0, 0, size.X, size.Y))
+	for y := range size.Y {
+		for x := range size.X {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), uint8(x ^ y), 0xFF})
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		b.Fatal(err)
+	}
+	ass := &immich.Asset{Data: buf.Bytes()}
+	screen := image.Pt(1920, 1080)
+	for b.Loop() {
+		if screenSized {
+			if _, err := decodeForScreen(ass, screen, 1); err != nil {
+				b.Fatal(err)
+			}
+			continue
+		}
+		// Decode the full image and scale it by a fraction of its height
+		// with Lanczos, as with an imageScale of 0.5.
+		img, _, err := image.Decode(bytes.NewReader(ass.Data))
+		if err != nil {
+			b.Fatal(err)
+		}
+		imaging.Resize(img, 0, img.Bounds().Dy()/2, imaging.Lanczos)
+	}
+}
+
+// BenchmarkDecodeFullsizeLanczos decodes a 12 megapixel fullsize rendition and
+// scales it by half its height with Lanczos.
+func BenchmarkDecodeFullsizeLanczos(b *testing.B) {
+	benchmarkDecode(b, image.Pt(4032, 3024), false)
+}
+
+// BenchmarkDecodeFullsizeScreen decodes a 12 megapixel fullsize rendition and
+// downscales it to the screen.
+func BenchmarkDecodeFullsizeScreen(b *testing.B) {
+	benchmarkDecode(b, image.Pt(4032, 3024), true)
+}
+
+// BenchmarkDecodePreviewScreen decodes the preview rendition, which the auto
+// quality picks for a 1080p screen, and downscales it to the screen.
+func BenchmarkDecodePreviewScreen(b *testing.B) {
+	benchmarkDecode(b, image.Pt(1920, 1440), true)
+}
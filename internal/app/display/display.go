@@ -1,11 +1,12 @@
 package display
 
 import (
-	"bytes"
 	"image"
 	"image/color"
 	"log/slog"
 	"math"
+	"strings"
+	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
@@ -27,23 +28,68 @@ import (
 // It is organized to take advantage of TOML parsing, however this package does
 // not handle parsing and has no expectation on how it will be initialized.
 type Config struct {
-	ImageScale float32
-	ImageText  []formatters.FormatConfig
+	ImageScale         float32
+	ImageText          []formatters.FormatConfig
+	Transition         Transition
+	TransitionDuration time.Duration
+	KenBurns           bool
+	BackgroundFill     BackgroundFill
+	// VideoAudio plays the audio of videos, which are otherwise muted.
+	VideoAudio bool
+	// VideoHardwareDecoding lets ffmpeg decode videos with a GPU, if it
+	// finds one, rather than in software.
+	VideoHardwareDecoding bool
+	// SlideDuration is how long each asset is shown. It is not configured
+	// directly, but set from the controller's image delay.
+	SlideDuration time.Duration `toml:"-"`
 }
 
+// initialWindowSize is the size of the window until it is shown full screen.
+var initialWindowSize = fyne.NewSize(200, 200)
+
 // Display controls the actual GUI application, such as the window, image, and
 // text overrlay.
 type Display struct {
 	conf  Config
 	win   fyne.Window
-	img   *canvas.Image
 	texts []*canvas.Text
+	// pairTexts are shown in the bottom-left for the left image of a pair.
+	pairTexts []*canvas.Text
+	// titleTexts are shown in the center for title cards.
+	titleTexts []*canvas.Text
+	pausedIcon *canvas.Image
+	// slides are stacked so the front slide can transition to the other.
+	slides      [2]*slide
+	front       int
+	slideLayer  *fyne.Container
+	transition  *fyne.Animation
+	finishTrans func()
+	kenBurns    *pausableAnimation
+	video       *videoPlayback
+	// paused freezes the Ken Burns effect and video. Like the fields above,
+	// it is only used from the main thread.
+	paused bool
 }
 
 // DecodedAsset is an asset that is ready to be displayed.
 type DecodedAsset struct {
 	Meta immich.AssetMetadata
 	Img  image.Image
+	// Background is drawn behind Img to fill the rest of the screen. It is
+	// nil if the background should be black.
+	Background image.Image
+	// Pair is the metadata of the asset shown to the right of Meta when
+	// two portrait images are shown side by side. See [Display.Pair].
+	Pair *immich.AssetMetadata
+	// Title holds the lines of text shown in the center instead of the
+	// configured image text. See [Display.TitleCard].
+	Title []string
+	// VideoPath is the file of a video played in place of Img, which is its
+	// still image, for up to Duration, or until it ends if Duration is 0.
+	// Img is shown again once the video ends. The video is read from the
+	// file as it plays, so assets in history don't hold it in memory.
+	VideoPath string
+	Duration  time.Duration
 }
 
 // New initializes a Display with the provided configuration.
@@ -53,35 +99,54 @@ func New(conf Config) *Display {
 	a.Settings().SetTheme(theme.DarkTheme())
 	a.Driver().SetDisableScreenBlanking(true)
 	win := a.NewWindow("immich")
-	win.Resize(fyne.NewSize(200, 200))
+	win.Resize(initialWindowSize)
 	win.SetFullScreen(true)
 
-	img := canvas.NewImageFromResource(nil)
-	img.FillMode = canvas.ImageFillContain
-	img.ScaleMode = canvas.ImageScaleSmooth
+	slides := [2]*slide{newSlide(), newSlide()}
+	slideLayer := container.NewStack(append(slides[0].objects(), slides[1].objects()...)...)
 
-	texts := make([]*canvas.Text, len(conf.ImageText))
-	textObjs := make([]fyne.CanvasObject, len(conf.ImageText))
-	for i := range len(conf.ImageText) {
-		texts[i] = canvas.NewText("", color.White)
-		texts[i].Alignment = fyne.TextAlignTrailing
-		texts[i].TextSize = conf.ImageText[i].Size()
-		textObjs[i] = texts[i]
+	texts, textBlock := newTextBlock(conf.ImageText, fyne.TextAlignTrailing)
+	pairTexts, pairTextBlock := newTextBlock(conf.ImageText, fyne.TextAlignLeading)
+	titleTexts := []*canvas.Text{
+		canvas.NewText("", color.White),
+		canvas.NewText("", color.White),
+	}
+	titleTexts[0].TextSize = 48
+	titleTexts[1].TextSize = 28
+	for _, text := range titleTexts {
+		text.Alignment = fyne.TextAlignCenter
 	}
 
-	textBlock := container.NewVBox(textObjs...)
+	// Create a small indicator to show when the slideshow is paused.
+	pausedIcon := canvas.NewImageFromResource(theme.MediaPauseIcon())
+	pausedIcon.FillMode = canvas.ImageFillContain
+	pausedIcon.SetMinSize(fyne.NewSize(32, 32))
+	pausedIcon.Hide()
 
-	// Create a container with the image and bottom-right aligned text.
+	// Create a container with the images, centered title text, top-right
+	// aligned paused indicator, bottom-right aligned text, and bottom-left
+	// aligned text for pairs.
 	content := container.NewStack(
-		img,
-		container.NewBorder(nil,
-			container.NewHBox(layout.NewSpacer(), textBlock),
+		slideLayer,
+		container.NewCenter(container.NewVBox(titleTexts[0], titleTexts[1])),
+		container.NewBorder(
+			container.NewHBox(layout.NewSpacer(), pausedIcon),
+			container.NewHBox(pairTextBlock, layout.NewSpacer(), textBlock),
 			nil, nil),
 		hiddenCursorOverlay{},
 	)
 	win.SetContent(content)
 
-	return &Display{conf, win, img, texts}
+	return &Display{
+		conf:       conf,
+		win:        win,
+		texts:      texts,
+		pairTexts:  pairTexts,
+		titleTexts: titleTexts,
+		pausedIcon: pausedIcon,
+		slides:     slides,
+		slideLayer: slideLayer,
+	}
 }
 
 // SetKeyBinds registers the provided callback to be executed when a key is
@@ -90,15 +155,132 @@ func (d *Display) SetKeyBinds(f func(*fyne.KeyEvent)) {
 	d.win.Canvas().SetOnTypedKey(f)
 }
 
-// Show tells the Display to display the DecodedAsset now.
+// Show tells the Display to display the DecodedAsset now, using the
+// configured transition. If a transition is still running, it is skipped so
+// rapidly showing assets does not queue up animations.
 func (d *Display) Show(da DecodedAsset) {
 	fyne.Do(func() {
 		slog.Info("displaying image", "name", da.Meta.Name, "id", da.Meta.ID)
-		d.img.Image = da.Img
-		d.img.Refresh()
+		d.skipTransition()
+		d.skipVideo()
+		from, to := d.slides[d.front], d.slides[1-d.front]
+		d.front = 1 - d.front
+		to.setAsset(da)
+		d.startTransition(from, to)
+		d.startVideo(to, da)
+		// Paired images are shown left to right, so the first asset's
+		// text goes on the left.
+		meta, pairMeta := da.Meta, immich.AssetMetadata{}
+		if da.Pair != nil {
+			meta, pairMeta = *da.Pair, da.Meta
+		}
 		for i := range d.texts {
-			d.texts[i].Text = d.conf.ImageText[i].Format(da.Meta)
+			d.texts[i].Text = ""
+			if da.Title == nil {
+				d.texts[i].Text = d.conf.ImageText[i].Format(meta)
+			}
 			d.texts[i].Refresh()
+			d.pairTexts[i].Text = ""
+			if da.Pair != nil {
+				d.pairTexts[i].Text = d.conf.ImageText[i].Format(pairMeta)
+			}
+			d.pairTexts[i].Refresh()
+		}
+		for i, text := range d.titleTexts {
+			text.Text = ""
+			if i < len(da.Title) {
+				text.Text = da.Title[i]
+			}
+			text.Refresh()
+		}
+	})
+}
+
+// startTransition is a helper method to animate from one slide to the other.
+// It must be called from the main thread.
+func (d *Display) startTransition(from, to *slide) {
+	anim, finish := d.conf.Transition.animation(d.conf.TransitionDuration, d.slideLayer.Size(), from, to)
+	if anim == nil {
+		d.startKenBurns(to)
+		return
+	}
+	tick := anim.Tick
+	anim.Tick = func(v float32) {
+		tick(v)
+		if v >= 1 {
+			finish()
+			d.transition, d.finishTrans = nil, nil
+			d.startKenBurns(to)
+		}
+	}
+	d.transition, d.finishTrans = anim, finish
+	anim.Start()
+}
+
+// startKenBurns is a helper method to slowly pan and zoom the slide for the
+// rest of the time it is shown, if configured. While paused, the slide waits at
+// the start of the motion. It must be called from the main thread.
+func (d *Display) startKenBurns(s *slide) {
+	if !d.conf.KenBurns || s.bounds.Empty() || s.video {
+		return
+	}
+	duration := d.conf.SlideDuration
+	if d.conf.Transition != TransitionNone {
+		duration -= d.conf.TransitionDuration
+	}
+	if duration <= 0 {
+		return
+	}
+	view := d.slideLayer.Size()
+	path := newKenBurnsPath(s.meta, s.bounds)
+	d.kenBurns = newPausableAnimation(duration, func(v float32) {
+		pos, size := path.at(v, view)
+		s.resize(size)
+		s.move(pos)
+	})
+	if d.paused {
+		d.kenBurns.tick(0)
+	} else {
+		d.kenBurns.resume()
+	}
+}
+
+// skipTransition is a helper method to immediately finish the running
+// transition and Ken Burns effect, if any. It must be called from the main
+// thread.
+func (d *Display) skipTransition() {
+	if d.kenBurns != nil {
+		d.kenBurns.pause()
+		d.kenBurns = nil
+	}
+	if d.transition == nil {
+		return
+	}
+	d.transition.Stop()
+	d.finishTrans()
+	d.transition, d.finishTrans = nil, nil
+}
+
+// SetPaused shows or hides the paused indicator, and freezes or continues the
+// Ken Burns effect and video of the asset being shown.
+func (d *Display) SetPaused(paused bool) {
+	fyne.Do(func() {
+		if d.paused == paused {
+			return
+		}
+		d.paused = paused
+		if paused {
+			d.pausedIcon.Show()
+			d.pauseVideo()
+			if d.kenBurns != nil {
+				d.kenBurns.pause()
+			}
+		} else {
+			d.pausedIcon.Hide()
+			d.resumeVideo()
+			if d.kenBurns != nil {
+				d.kenBurns.resume()
+			}
 		}
 	})
 }
@@ -107,21 +289,108 @@ func (d *Display) Show(da DecodedAsset) {
 // display. This allows the work to be done ahead of calling [Show], since
 // decoding can be a significant amount of work.
 func (d *Display) DecodeAsset(ass *immich.Asset) (*DecodedAsset, error) {
-	img, _, err := image.Decode(bytes.NewReader(ass.Data))
+	img, err := decodeForScreen(ass, d.PixelSize(), d.conf.ImageScale)
 	if err != nil {
 		return nil, err
 	}
-	imgHeight := img.Bounds().Dy()
-	resizeHeight := int(float32(imgHeight) * d.conf.ImageScale)
-	if resizeHeight != imgHeight {
-		img = imaging.Resize(img, 0, resizeHeight, imaging.Lanczos)
-	}
 	return &DecodedAsset{
-		Meta: ass.Meta,
-		Img:  img,
+		Meta:       ass.Meta,
+		Img:        img,
+		Background: d.conf.BackgroundFill.background(img, d.viewSize()),
 	}, nil
 }
 
+// Pair combines two decoded assets into a single DecodedAsset that shows them
+// side by side, scaled to the same height. Like [DecodeAsset], this can be a
+// significant amount of work so it should be done ahead of calling [Show].
+func (d *Display) Pair(left, right DecodedAsset) *DecodedAsset {
+	height := min(left.Img.Bounds().Dy(), right.Img.Bounds().Dy())
+	leftImg := scaleToHeight(left.Img, height)
+	rightImg := scaleToHeight(right.Img, height)
+	gap := height / 100
+
+	leftWidth := leftImg.Bounds().Dx()
+	img := imaging.New(leftWidth+gap+rightImg.Bounds().Dx(), height, color.Black)
+	img = imaging.Paste(img, leftImg, image.Pt(0, 0))
+	img = imaging.Paste(img, rightImg, image.Pt(leftWidth+gap, 0))
+	return &DecodedAsset{
+		Meta:       left.Meta,
+		Img:        img,
+		Background: d.conf.BackgroundFill.background(img, d.viewSize()),
+		Pair:       &right.Meta,
+	}
+}
+
+// scaleToHeight is a helper function to shrink img to the height. Since images
+// are already fit to the screen by [decodeForScreen], only the taller of a pair
+// is scaled, and only by a little, so the cheap Box filter is used like it is
+// when decoding.
+func scaleToHeight(img image.Image, height int) image.Image {
+	if img.Bounds().Dy() == height {
+		return img
+	}
+	return imaging.Resize(img, 0, height, imaging.Box)
+}
+
+// TitleCard creates a DecodedAsset that shows the lines of the metadata's
+// Name as a title on a black background. Only the first two lines are shown.
+func (d *Display) TitleCard(md immich.AssetMetadata) *DecodedAsset {
+	return &DecodedAsset{
+		Meta:  md,
+		Img:   imaging.New(1, 1, color.Black),
+		Title: strings.Split(md.Name, "\n"),
+	}
+}
+
+// IsLandscape reports whether the area images are displayed in is wider than
+// it is tall.
+func (d *Display) IsLandscape() bool {
+	size := d.viewSize()
+	return size.Width > size.Height
+}
+
+// PixelSize returns the size in pixels of the area images are displayed in. It
+// is zero until the window is shown.
+func (d *Display) PixelSize() image.Point {
+	c := d.win.Canvas()
+	size := c.Size()
+	if size == initialWindowSize {
+		return image.Point{}
+	}
+	return image.Pt(int(size.Width*c.Scale()), int(size.Height*c.Scale()))
+}
+
+// RenditionSize returns how many pixels long the shortest side of the asset's
+// image needs to be to cover its area of the screen, scaled by the configured
+// ImageScale. It is zero until the window is shown.
+func (d *Display) RenditionSize(md immich.AssetMetadata) int {
+	screen := d.PixelSize()
+	if screen == (image.Point{}) {
+		return 0
+	}
+	w, h := md.ExifInfo.Dimensions()
+	return renditionSize(image.Pt(w, h), screen, d.conf.ImageScale)
+}
+
+// viewSize returns the size of the area images are displayed in.
+func (d *Display) viewSize() fyne.Size {
+	return d.win.Canvas().Size()
+}
+
+// newTextBlock is a helper function to create the text objects for the
+// configured formatters, stacked in a vertical box.
+func newTextBlock(conf []formatters.FormatConfig, align fyne.TextAlign) ([]*canvas.Text, *fyne.Container) {
+	texts := make([]*canvas.Text, len(conf))
+	textObjs := make([]fyne.CanvasObject, len(conf))
+	for i := range len(conf) {
+		texts[i] = canvas.NewText("", color.White)
+		texts[i].Alignment = align
+		texts[i].TextSize = conf[i].Size()
+		textObjs[i] = texts[i]
+	}
+	return texts, container.NewVBox(textObjs...)
+}
+
 // ShowAndRun starts the GUI and runs the application. This method must be
 // called from the main thread and blocks until the application is closed.
 func (d *Display) ShowAndRun() {
@@ -0,0 +1,159 @@
+package display
+
+import (
+	"image"
+	"math/rand/v2"
+	"time"
+
+	"fyne.io/fyne/v2"
+
+	"immich-photo-frame/internal/immich"
+)
+
+// kenBurnsMaxScale is how far the Ken Burns effect zooms into an image.
+const kenBurnsMaxScale = 1.2
+
+// kenBurnsFrame is a point along a Ken Burns motion path.
+type kenBurnsFrame struct {
+	// scale is how much larger than the view the image is drawn.
+	scale float32
+	// focusX and focusY are the normalized point in the image (0 to 1 on
+	// each axis) to keep in the center of the view.
+	focusX, focusY float32
+}
+
+// kenBurnsPath is a slow pan and zoom between two frames.
+type kenBurnsPath struct {
+	start, end kenBurnsFrame
+	imgSize    fyne.Size
+}
+
+// newKenBurnsPath chooses a motion path for the image. If the asset has
+// detected faces, the path zooms towards (or away from) them while keeping
+// them all in frame. Otherwise it zooms towards a random point near the
+// center.
+func newKenBurnsPath(md immich.AssetMetadata, bounds image.Rectangle) kenBurnsPath {
+	start := kenBurnsFrame{scale: 1, focusX: 0.5, focusY: 0.5}
+	end := kenBurnsFrame{
+		scale:  kenBurnsMaxScale,
+		focusX: 0.3 + 0.4*rand.Float32(),
+		focusY: 0.3 + 0.4*rand.Float32(),
+	}
+	if x1, y1, x2, y2, ok := faceRegion(md); ok {
+		end.focusX, end.focusY = (x1+x2)/2, (y1+y2)/2
+		// Zooming in by scale shows roughly 1/scale of the image, so
+		// limit the zoom to keep every face in the view with a margin.
+		end.scale = max(1, min(kenBurnsMaxScale, 0.9/max(x2-x1, y2-y1)))
+	}
+	// Alternate between zooming in and out so it doesn't feel repetitive.
+	if rand.IntN(2) == 0 {
+		start, end = end, start
+	}
+	return kenBurnsPath{
+		start:   start,
+		end:     end,
+		imgSize: fyne.NewSize(float32(bounds.Dx()), float32(bounds.Dy())),
+	}
+}
+
+// at calculates the position and size of the image object in the view at
+// progress v, from 0 to 1. The image object is assumed to use
+// canvas.ImageFillContain.
+func (p kenBurnsPath) at(v float32, view fyne.Size) (fyne.Position, fyne.Size) {
+	lerp := func(a, b float32) float32 { return a + (b-a)*v }
+	scale := lerp(p.start.scale, p.end.scale)
+	focusX, focusY := lerp(p.start.focusX, p.end.focusX), lerp(p.start.focusY, p.end.focusY)
+
+	// Size of the object and of the image drawn inside of it.
+	size := fyne.NewSize(view.Width*scale, view.Height*scale)
+	fit := min(size.Width/p.imgSize.Width, size.Height/p.imgSize.Height)
+	drawn := fyne.NewSize(p.imgSize.Width*fit, p.imgSize.Height*fit)
+	offset := fyne.NewPos((size.Width-drawn.Width)/2, (size.Height-drawn.Height)/2)
+
+	pos := fyne.NewPos(
+		kenBurnsAxis(view.Width, size.Width, drawn.Width, offset.X, focusX),
+		kenBurnsAxis(view.Height, size.Height, drawn.Height, offset.Y, focusY),
+	)
+	return pos, size
+}
+
+// kenBurnsAxis is a helper function to calculate the position of the image
+// object along one axis. The focus point is centered in the view, but the
+// position is clamped so the drawn image never leaves a gap it could cover.
+// If the drawn image is smaller than the view, it is centered instead.
+func kenBurnsAxis(view, size, drawn, offset, focus float32) float32 {
+	if drawn <= view {
+		return (view - size) / 2
+	}
+	pos := view/2 - (offset + focus*drawn)
+	// The drawn image starts at pos+offset and ends at pos+offset+drawn.
+	return min(max(pos, view-offset-drawn), -offset)
+}
+
+// faceRegion is a helper function to find the normalized bounding box (0 to 1
+// on each axis) containing every detected face in the asset.
+func faceRegion(md immich.AssetMetadata) (x1, y1, x2, y2 float32, ok bool) {
+	x1, y1 = 1, 1
+	for _, person := range md.People {
+		for _, face := range person.Faces {
+			fx1, fy1, fx2, fy2, fok := face.Region()
+			if !fok {
+				continue
+			}
+			x1, y1 = min(x1, fx1), min(y1, fy1)
+			x2, y2 = max(x2, fx2), max(y2, fy2)
+			ok = true
+		}
+	}
+	return x1, y1, x2, y2, ok && x2 > x1 && y2 > y1
+}
+
+// pausableAnimation is a linear animation that can be paused and resumed where
+// it left off. A fyne.Animation can only be stopped, so each resume starts a
+// new one over the remaining progress. It must only be used from the main
+// thread.
+type pausableAnimation struct {
+	duration time.Duration
+	tick     func(float32)
+	progress float32
+	anim     *fyne.Animation
+}
+
+func newPausableAnimation(duration time.Duration, tick func(float32)) *pausableAnimation {
+	return &pausableAnimation{duration: duration, tick: tick}
+}
+
+// resume starts or continues the animation. It does nothing if it is already
+// running or has finished.
+func (a *pausableAnimation) resume() {
+	if a.anim != nil || a.progress >= 1 {
+		return
+	}
+	from := a.progress
+	a.anim = fyne.NewAnimation(remainingDuration(a.duration, from), func(v float32) {
+		a.progress = resumedProgress(from, v)
+		a.tick(a.progress)
+	})
+	a.anim.Curve = fyne.AnimationLinear
+	a.anim.Start()
+}
+
+// pause stops the animation, keeping its progress for [resume].
+func (a *pausableAnimation) pause() {
+	if a.anim != nil {
+		a.anim.Stop()
+		a.anim = nil
+	}
+}
+
+// remainingDuration is a helper function to get how much of the duration is
+// left after progress, from 0 to 1.
+func remainingDuration(duration time.Duration, progress float32) time.Duration {
+	return time.Duration(float64(duration) * float64(1-progress))
+}
+
+// resumedProgress is a helper function to convert the progress v of an
+// animation resumed at progress from into the progress of the whole animation.
+func resumedProgress(from, v float32) float32 {
+	return from + v*(1-from)
+}
@@ -0,0 +1,158 @@
+package display
+
+import (
+	"image"
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2"
+
+	"immich-photo-frame/internal/immich"
+	"immich-photo-frame/internal/immich/api"
+)
+
+// withFaces is a helper function to create metadata for a 1000x500 image with
+// faces at the provided pixel bounding boxes.
+func withFaces(boxes ...image.Rectangle) immich.AssetMetadata {
+	var person api.Person
+	for _, box := range boxes {
+		person.Faces = append(person.Faces, api.Face{
+			ImageWidth:    1000,
+			ImageHeight:   500,
+			BoundingBoxX1: box.Min.X,
+			BoundingBoxY1: box.Min.Y,
+			BoundingBoxX2: box.Max.X,
+			BoundingBoxY2: box.Max.Y,
+		})
+	}
+	return immich.AssetMetadata{People: []api.Person{person}}
+}
+
+func TestFaceRegion(t *testing.T) {
+	for _, tt := range []struct {
+		name           string
+		md             immich.AssetMetadata
+		x1, y1, x2, y2 float32
+		ok             bool
+	}{
+		{"no people", immich.AssetMetadata{}, 0, 0, 0, 0, false},
+		{"one face", withFaces(image.Rect(100, 100, 300, 200)), 0.1, 0.2, 0.3, 0.4, true},
+		{"every face", withFaces(image.Rect(100, 100, 300, 200), image.Rect(600, 50, 700, 150)), 0.1, 0.1, 0.7, 0.4, true},
+		{"empty face", withFaces(image.Rect(100, 100, 100, 200)), 0, 0, 0, 0, false},
+		{"no dimensions", immich.AssetMetadata{People: []api.Person{{Faces: []api.Face{{BoundingBoxX2: 10, BoundingBoxY2: 10}}}}}, 0, 0, 0, 0, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			x1, y1, x2, y2, ok := faceRegion(tt.md)
+			if ok != tt.ok || (ok && (x1 != tt.x1 || y1 != tt.y1 || x2 != tt.x2 || y2 != tt.y2)) {
+				t.Errorf("faceRegion() = %v, %v, %v, %v, %t, want %v, %v, %v, %v, %t",
+					x1, y1, x2, y2, ok, tt.x1, tt.y1, tt.x2, tt.y2, tt.ok)
+			}
+		})
+	}
+}
+
+func TestKenBurnsPathFocusesOnFaces(t *testing.T) {
+	bounds := image.Rect(0, 0, 1000, 500)
+	for _, tt := range []struct {
+		name           string
+		md             immich.AssetMetadata
+		focusX, focusY float32
+		scale          float32
+	}{
+		// A small face is zoomed in on as far as allowed.
+		{"small face", withFaces(image.Rect(100, 100, 300, 200)), 0.2, 0.3, kenBurnsMaxScale},
+		// Faces spanning most of the image limit the zoom so they all
+		// stay in view.
+		{"spread faces", withFaces(image.Rect(100, 100, 200, 200), image.Rect(850, 300, 950, 400)), 0.525, 0.5, 0.9 / 0.85},
+		{"whole image", withFaces(image.Rect(0, 0, 1000, 500)), 0.5, 0.5, 1},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			// The path randomly zooms in or out, so check both.
+			for range 10 {
+				p := newKenBurnsPath(tt.md, bounds)
+				frame := p.end
+				if p.start != (kenBurnsFrame{scale: 1, focusX: 0.5, focusY: 0.5}) {
+					frame = p.start
+				}
+				want := kenBurnsFrame{scale: tt.scale, focusX: tt.focusX, focusY: tt.focusY}
+				if !closeTo(frame.scale, want.scale) || !closeTo(frame.focusX, want.focusX) || !closeTo(frame.focusY, want.focusY) {
+					t.Fatalf("expected the path to focus on %+v, got %+v", want, p)
+				}
+			}
+		})
+	}
+}
+
+func TestKenBurnsPathAt(t *testing.T) {
+	view := fyne.NewSize(1000, 500)
+	for _, tt := range []struct {
+		name string
+		path kenBurnsPath
+		v    float32
+		pos  fyne.Position
+		size fyne.Size
+	}{
+		{
+			name: "fills view",
+			path: kenBurnsPath{start: kenBurnsFrame{1, 0.5, 0.5}, end: kenBurnsFrame{2, 0.5, 0.5}, imgSize: view},
+			pos:  fyne.NewPos(0, 0),
+			size: view,
+		},
+		{
+			name: "zoomed to center",
+			path: kenBurnsPath{start: kenBurnsFrame{1, 0.5, 0.5}, end: kenBurnsFrame{2, 0.5, 0.5}, imgSize: view},
+			v:    1,
+			pos:  fyne.NewPos(-500, -250),
+			size: fyne.NewSize(2000, 1000),
+		},
+		{
+			// Focusing on a corner is clamped so no gap is left.
+			name: "clamped to corner",
+			path: kenBurnsPath{start: kenBurnsFrame{2, 0, 0}, end: kenBurnsFrame{2, 0, 0}, imgSize: view},
+			pos:  fyne.NewPos(0, 0),
+			size: fyne.NewSize(2000, 1000),
+		},
+		{
+			// A portrait image narrower than the zoomed view stays
+			// centered horizontally.
+			name: "portrait",
+			path: kenBurnsPath{start: kenBurnsFrame{1.2, 0, 0.5}, end: kenBurnsFrame{1.2, 0, 0.5}, imgSize: fyne.NewSize(300, 600)},
+			pos:  fyne.NewPos(-100, -50),
+			size: fyne.NewSize(1200, 600),
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			pos, size := tt.path.at(tt.v, view)
+			if !closeTo(pos.X, tt.pos.X) || !closeTo(pos.Y, tt.pos.Y) || size != tt.size {
+				t.Errorf("at(%v) = %v, %v, want %v, %v", tt.v, pos, size, tt.pos, tt.size)
+			}
+		})
+	}
+}
+
+// closeTo is a helper function to compare floats with some tolerance for
+// rounding.
+func closeTo(a, b float32) bool {
+	return a-b < 1e-4 && b-a < 1e-4
+}
+
+func TestPausableAnimationProgress(t *testing.T) {
+	for _, tt := range []struct {
+		from, v  float32
+		progress float32
+		remains  time.Duration
+	}{
+		{0, 0, 0, 10 * time.Second},
+		{0, 0.5, 0.5, 10 * time.Second},
+		{0.5, 0, 0.5, 5 * time.Second},
+		{0.5, 0.5, 0.75, 5 * time.Second},
+		{0.75, 1, 1, 2500 * time.Millisecond},
+	} {
+		if got := resumedProgress(tt.from, tt.v); got != tt.progress {
+			t.Errorf("resumedProgress(%v, %v) = %v, want %v", tt.from, tt.v, got, tt.progress)
+		}
+		if got := remainingDuration(10*time.Second, tt.from); got != tt.remains {
+			t.Errorf("remainingDuration(10s, %v) = %v, want %v", tt.from, got, tt.remains)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package display
+
+import (
+	"fmt"
+	"image"
+	"slices"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+
+	"immich-photo-frame/internal/immich"
+)
+
+// Transition is the animation used when switching from one image to the next.
+type Transition string
+
+const (
+	TransitionNone      Transition = "none"
+	TransitionCrossfade Transition = "crossfade"
+	TransitionSlide     Transition = "slide"
+	TransitionZoom      Transition = "zoom"
+)
+
+// transitions is the list of all the Transitions that can be configured.
+var transitions = []Transition{
+	TransitionNone,
+	TransitionCrossfade,
+	TransitionSlide,
+	TransitionZoom,
+}
+
+// UnmarshalText implements toml.TextUnmarshaler.
+func (t *Transition) UnmarshalText(text []byte) error {
+	name := Transition(strings.ToLower(string(text)))
+	if slices.Contains(transitions, name) {
+		*t = name
+		return nil
+	}
+	var validTransitions []string
+	for _, transition := range transitions {
+		validTransitions = append(validTransitions, fmt.Sprintf("%q", transition))
+	}
+	return fmt.Errorf(
+		"unsupported transition %q, expected one of %v",
+		string(text), validTransitions,
+	)
+}
+
+// animation creates the animation to switch from the old slide to the new
+// slide, which must already be showing the new image. The returned finish
+// function immediately applies the final state of the transition and must be
+// called when the animation completes or is skipped. The animation is nil if
+// there is nothing to animate.
+func (t Transition) animation(duration time.Duration, size fyne.Size, from, to *slide) (*fyne.Animation, func()) {
+	finish := func() {
+		from.hide()
+		from.reset(size)
+		to.reset(size)
+	}
+	to.reset(size)
+	to.show()
+
+	var tick func(float32)
+	switch t {
+	case TransitionCrossfade:
+		tick = func(v float32) {
+			from.setTranslucency(float64(v))
+			to.setTranslucency(float64(1 - v))
+		}
+	case TransitionSlide:
+		// The old slide may have been moved by the Ken Burns effect.
+		fromPos := from.img.Position()
+		tick = func(v float32) {
+			from.move(fromPos.SubtractXY(v*size.Width, 0))
+			to.move(fyne.NewPos((1-v)*size.Width, 0))
+		}
+	case TransitionZoom:
+		const startScale = 0.85
+		tick = func(v float32) {
+			scale := startScale + (1-startScale)*v
+			scaled := size.Subtract(fyne.NewSize(size.Width*(1-scale), size.Height*(1-scale)))
+			to.resize(scaled)
+			to.move(fyne.NewPos((size.Width-scaled.Width)/2, (size.Height-scaled.Height)/2))
+			to.setTranslucency(float64(1 - v))
+			from.setTranslucency(float64(v))
+		}
+	}
+	if tick == nil || duration <= 0 {
+		finish()
+		return nil, func() {}
+	}
+	tick(0)
+	return fyne.NewAnimation(duration, tick), finish
+}
+
+// slide is a layer of the Display that shows a single image. Two slides are
+// stacked so one can transition into the other.
+type slide struct {
+	img    *canvas.Image
+	bg     *canvas.Image
+	meta   immich.AssetMetadata
+	bounds image.Rectangle
+	// video is set if the slide is playing a video, which is not panned
+	// or zoomed.
+	video bool
+}
+
+// newSlide initializes an empty, hidden slide.
+func newSlide() *slide {
+	img := canvas.NewImageFromResource(nil)
+	img.FillMode = canvas.ImageFillContain
+	img.ScaleMode = canvas.ImageScaleSmooth
+	img.Hide()
+	bg := canvas.NewImageFromResource(nil)
+	bg.FillMode = canvas.ImageFillCover
+	bg.ScaleMode = canvas.ImageScaleSmooth
+	bg.Hide()
+	return &slide{img: img, bg: bg}
+}
+
+// objects returns the canvas objects of the slide, bottom first.
+func (s *slide) objects() []fyne.CanvasObject {
+	return []fyne.CanvasObject{s.bg, s.img}
+}
+
+// setAsset updates the slide to show the decoded asset.
+func (s *slide) setAsset(da DecodedAsset) {
+	s.meta = da.Meta
+	if da.Pair != nil {
+		// Face positions are relative to a single asset, so they do not
+		// apply to a pair.
+		s.meta = immich.AssetMetadata{}
+	}
+	s.bounds = da.Img.Bounds()
+	s.video = da.VideoPath != ""
+	s.img.Image = da.Img
+	s.img.Refresh()
+	s.bg.Image = da.Background
+	s.bg.Refresh()
+}
+
+// setTranslucency sets how transparent the slide is, from 0 (opaque) to 1
+// (invisible).
+func (s *slide) setTranslucency(v float64) {
+	s.img.Translucency = v
+	s.img.Refresh()
+	s.bg.Translucency = v
+	s.bg.Refresh()
+}
+
+func (s *slide) move(pos fyne.Position) { s.img.Move(pos); s.bg.Move(pos) }
+func (s *slide) resize(size fyne.Size)  { s.img.Resize(size); s.bg.Resize(size) }
+func (s *slide) hide()                  { s.img.Hide(); s.bg.Hide() }
+
+// show makes the slide visible. The background is only shown if there is
+// one, otherwise the black window shows through.
+func (s *slide) show() {
+	s.img.Show()
+	if s.bg.Image != nil {
+		s.bg.Show()
+	} else {
+		s.bg.Hide()
+	}
+}
+
+// reset restores the slide to be opaque and fill the provided size.
+func (s *slide) reset(size fyne.Size) {
+	s.move(fyne.NewPos(0, 0))
+	s.resize(size)
+	s.setTranslucency(0)
+}
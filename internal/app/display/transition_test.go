@@ -0,0 +1,107 @@
+package display
+
+import (
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+)
+
+func TestTransitionUnmarshalText(t *testing.T) {
+	for _, tt := range []struct {
+		text string
+		want Transition
+		ok   bool
+	}{
+		{"none", TransitionNone, true},
+		{"Crossfade", TransitionCrossfade, true},
+		{"SLIDE", TransitionSlide, true},
+		{"zoom", TransitionZoom, true},
+		{"wipe", "", false},
+		{"", "", false},
+	} {
+		var got Transition
+		err := got.UnmarshalText([]byte(tt.text))
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("UnmarshalText(%q) = %q, %v, want %q, ok %t", tt.text, got, err, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestTransitionAnimation(t *testing.T) {
+	test.NewTempApp(t)
+	size := fyne.NewSize(1000, 500)
+	type state struct {
+		fromPos, toPos                   fyne.Position
+		toSize                           fyne.Size
+		fromTranslucency, toTranslucency float64
+	}
+	for _, tt := range []struct {
+		transition Transition
+		// halfway is the state of the slides halfway through the
+		// transition.
+		halfway state
+	}{
+		{TransitionCrossfade, state{toSize: size, fromTranslucency: 0.5, toTranslucency: 0.5}},
+		{TransitionSlide, state{fromPos: fyne.NewPos(-500, 0), toPos: fyne.NewPos(500, 0), toSize: size}},
+		{TransitionZoom, state{
+			toPos:            fyne.NewPos(37.5, 18.75),
+			toSize:           fyne.NewSize(925, 462.5),
+			fromTranslucency: 0.5,
+			toTranslucency:   0.5,
+		}},
+	} {
+		t.Run(string(tt.transition), func(t *testing.T) {
+			from, to := newSlide(), newSlide()
+			anim, finish := tt.transition.animation(time.Second, size, from, to)
+			if anim == nil {
+				t.Fatal("expected an animation")
+			}
+			anim.Tick(0.5)
+			got := state{
+				fromPos:          from.img.Position(),
+				toPos:            to.img.Position(),
+				toSize:           to.img.Size(),
+				fromTranslucency: from.img.Translucency,
+				toTranslucency:   to.img.Translucency,
+			}
+			if got != tt.halfway {
+				t.Errorf("halfway through got %+v, want %+v", got, tt.halfway)
+			}
+
+			anim.Tick(1)
+			finish()
+			if from.img.Visible() || !to.img.Visible() {
+				t.Error("expected only the new slide to be visible after the transition")
+			}
+			if to.img.Position() != (fyne.Position{}) || to.img.Size() != size || to.img.Translucency != 0 {
+				t.Errorf("expected the new slide to fill the view, got %v %v translucency %v",
+					to.img.Position(), to.img.Size(), to.img.Translucency)
+			}
+		})
+	}
+}
+
+func TestTransitionNone(t *testing.T) {
+	test.NewTempApp(t)
+	size := fyne.NewSize(1000, 500)
+	for _, tt := range []struct {
+		transition Transition
+		duration   time.Duration
+	}{
+		{TransitionNone, time.Second},
+		{TransitionCrossfade, 0},
+	} {
+		from, to := newSlide(), newSlide()
+		from.show()
+		anim, finish := tt.transition.animation(tt.duration, size, from, to)
+		if anim != nil {
+			t.Errorf("%s for %v: expected no animation", tt.transition, tt.duration)
+		}
+		finish()
+		if from.img.Visible() || !to.img.Visible() || to.img.Size() != size {
+			t.Errorf("%s for %v: expected the new slide to be shown immediately", tt.transition, tt.duration)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package display
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"io"
+	"log/slog"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+)
+
+// videoFrameRate is the frame rate videos are decoded at, which limits the
+// work done on devices that decode in software.
+const videoFrameRate = 24
+
+// videoPlayback is the video of the asset being shown. Its fields are only
+// used from the main thread.
+type videoPlayback struct {
+	slide *slide
+	da    DecodedAsset
+	// position is how far into the video the last drawn frame is, so it
+	// can be resumed from there after being paused.
+	position time.Duration
+	// stop stops the video while it is playing, and is nil otherwise.
+	stop context.CancelFunc
+	done bool
+}
+
+// startVideo is a helper method to play the asset's video on the slide, if it
+// has one, for up to the asset's Duration. Once the video ends, the slide
+// settles on the still image. While paused, the still image is shown until
+// the video is resumed. It must be called from the main thread.
+func (d *Display) startVideo(s *slide, da DecodedAsset) {
+	if da.VideoPath == "" {
+		return
+	}
+	d.video = &videoPlayback{slide: s, da: da}
+	if !d.paused {
+		d.resumeVideo()
+	}
+}
+
+// resumeVideo is a helper method to play the video from where it was paused,
+// if there is one that has not ended. It must be called from the main thread.
+func (d *Display) resumeVideo() {
+	v := d.video
+	if v == nil || v.stop != nil || v.done {
+		return
+	}
+	ctx, stop := context.WithCancel(context.Background())
+	v.stop = stop
+	start := v.position
+	go func() {
+		playCtx, cancel := ctx, context.CancelFunc(func() {})
+		if v.da.Duration > 0 {
+			playCtx, cancel = context.WithTimeout(ctx, v.da.Duration-start)
+		}
+		defer cancel()
+		err := d.playVideo(playCtx, v.da, start, func(frame *image.RGBA) {
+			fyne.DoAndWait(func() {
+				if ctx.Err() == nil {
+					v.slide.img.Image = frame
+					v.slide.img.Refresh()
+					v.position += time.Second / videoFrameRate
+				}
+			})
+		})
+		if err != nil && playCtx.Err() == nil {
+			slog.Error("failed to play video", "id", v.da.Meta.ID, "error", err)
+		}
+		fyne.Do(func() {
+			if ctx.Err() == nil {
+				v.slide.img.Image = v.da.Img
+				v.slide.img.Refresh()
+				v.stop, v.done = nil, true
+			}
+		})
+	}()
+}
+
+// pauseVideo is a helper method to stop the video that is playing, if any,
+// leaving its last frame on the slide so it can be resumed. It must be called
+// from the main thread.
+func (d *Display) pauseVideo() {
+	if d.video != nil && d.video.stop != nil {
+		d.video.stop()
+		d.video.stop = nil
+	}
+}
+
+// skipVideo is a helper method to stop the video that is playing, if any, for
+// good. It must be called from the main thread.
+func (d *Display) skipVideo() {
+	d.pauseVideo()
+	d.video = nil
+}
+
+// playVideo is a helper method to decode the asset's video with ffmpeg in real
+// time from start, scaled to the size of its still image, and draw each frame.
+// If configured, the audio is played with ffplay. It blocks until the video
+// ends or ctx is done.
+func (d *Display) playVideo(ctx context.Context, da DecodedAsset, start time.Duration, draw func(*image.RGBA)) error {
+	seek := []string{"-ss", fmt.Sprintf("%.3f", start.Seconds())}
+	if d.conf.VideoAudio {
+		audio := exec.CommandContext(ctx, "ffplay", slices.Concat([]string{"-nodisp", "-autoexit", "-loglevel", "error"}, seek, []string{da.VideoPath})...)
+		if err := audio.Start(); err != nil {
+			slog.Warn("failed to play video audio", "id", da.Meta.ID, "error", err)
+		} else {
+			defer audio.Wait()
+		}
+	}
+
+	size := da.Img.Bounds().Size()
+	args := []string{"-loglevel", "error"}
+	if d.conf.VideoHardwareDecoding {
+		args = append(args, "-hwaccel", "auto")
+	}
+	args = append(args, seek...)
+	args = append(args,
+		"-re", "-i", da.VideoPath, "-an",
+		"-vf", fmt.Sprintf("fps=%d,scale=%d:%d", videoFrameRate, size.X, size.Y),
+		"-f", "rawvideo", "-pix_fmt", "rgba", "pipe:1",
+	)
+	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	// Rotate between a few frames so the next one can be decoded while the
+	// previous one is still being drawn.
+	var frames [3]*image.RGBA
+	for i := 0; ; i = (i + 1) % len(frames) {
+		if frames[i] == nil {
+			frames[i] = image.NewRGBA(image.Rect(0, 0, size.X, size.Y))
+		}
+		if _, err := io.ReadFull(out, frames[i].Pix); err != nil {
+			break
+		}
+		draw(frames[i])
+	}
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package display
+
+import (
+	"image"
+	"testing"
+
+	"fyne.io/fyne/v2/test"
+
+	"immich-photo-frame/internal/immich"
+)
+
+func TestStartVideo(t *testing.T) {
+	test.NewTempApp(t)
+	s := newSlide()
+	d := &Display{paused: true}
+	still := DecodedAsset{Meta: immich.AssetMetadata{ID: "image-1"}, Img: image.NewGray(image.Rect(0, 0, 1, 1))}
+	s.setAsset(still)
+	d.startVideo(s, still)
+	if s.video || d.video != nil {
+		t.Fatal("expected no video for a still image")
+	}
+
+	video := still
+	video.VideoPath = "video.mp4"
+	s.setAsset(video)
+	d.startVideo(s, video)
+	if !s.video {
+		t.Error("expected the slide to have a video")
+	}
+	// While paused, the video waits to be resumed.
+	if d.video == nil || d.video.da.VideoPath != "video.mp4" || d.video.stop != nil {
+		t.Fatalf("expected the video to wait while paused, got %+v", d.video)
+	}
+	d.skipVideo()
+	if d.video != nil {
+		t.Error("expected the video to be skipped")
+	}
+}
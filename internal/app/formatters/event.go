@@ -0,0 +1,61 @@
+package formatters
+
+import (
+	"fmt"
+	"time"
+
+	"immich-photo-frame/internal/immich"
+)
+
+// FormatEvent describes a group of assets by the location of the first asset
+// that has one and the range of dates the assets were taken, such as
+// "Paris, France" and "July 1 – 3, 2024". Either is empty if it is unknown.
+func FormatEvent(mds []immich.AssetMetadata) (location, dates string) {
+	var first, last time.Time
+	for _, md := range mds {
+		if location == "" {
+			location = ImageLocation{}.Format(md)
+		}
+		t, ok := assetTime(md)
+		if !ok {
+			continue
+		}
+		if first.IsZero() || t.Before(first) {
+			first = t
+		}
+		if last.IsZero() || t.After(last) {
+			last = t
+		}
+	}
+	if first.IsZero() {
+		return location, ""
+	}
+	return location, formatDateRange(first, last)
+}
+
+// formatDateRange is a helper function to format a range of dates, only
+// repeating the month and year when they differ.
+func formatDateRange(first, last time.Time) string {
+	switch {
+	case first.Year() != last.Year():
+		return fmt.Sprintf("%s – %s", first.Format("January 2, 2006"), last.Format("January 2, 2006"))
+	case first.Month() != last.Month():
+		return fmt.Sprintf("%s – %s", first.Format("January 2"), last.Format("January 2, 2006"))
+	case first.Day() != last.Day():
+		return fmt.Sprintf("%s – %d, %d", first.Format("January 2"), last.Day(), last.Year())
+	}
+	return first.Format("January 2, 2006")
+}
+
+// assetTime is a helper function to get when the asset was taken, in the
+// asset's timezone if it is known.
+func assetTime(md immich.AssetMetadata) (time.Time, bool) {
+	t, err := time.Parse("2006-01-02T15:04:05.999Z07:00", md.ExifInfo.DateTimeOriginal)
+	if err != nil {
+		return time.Time{}, false
+	}
+	if loc, err := parseTimeZone(md.ExifInfo.TimeZone); err == nil {
+		t = t.In(loc)
+	}
+	return t, true
+}
@@ -0,0 +1,161 @@
+package server
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"immich-photo-frame/internal/app/controller/planners"
+	"immich-photo-frame/internal/immich"
+)
+
+// Config holds configuration values for the HTTP control server.
+//
+// It is organized to take advantage of TOML parsing, however this package does
+// not handle parsing and has no expectation on how it will be initialized.
+type Config struct {
+	UseServer     bool
+	ListenAddress string
+}
+
+// Controller describes the photo-frame controls exposed by the Server. It is
+// implemented by controller.Controller.
+type Controller interface {
+	Next() error
+	Prev() error
+	Pause() error
+	Resume() error
+	Paused() bool
+	Current() (md immich.AssetMetadata, pair *immich.AssetMetadata, ok bool)
+	History() ([]immich.AssetMetadata, int)
+	PlannerStatus() planners.Status
+}
+
+// Server exposes a Controller as a JSON HTTP API.
+//
+// Endpoints:
+//
+//	POST /next     show the next asset
+//	POST /prev     show the previous asset
+//	POST /pause    stop advancing automatically
+//	POST /resume   start advancing automatically
+//	GET  /current  metadata of the asset being displayed
+//	GET  /history  metadata of the assets in history
+//	GET  /planner  progress of the plan algorithm
+type Server struct {
+	conf Config
+	ctrl Controller
+}
+
+// The API only serves small JSON responses, so these timeouts are short to
+// keep slow or idle clients from holding connections open.
+const (
+	readHeaderTimeout = 5 * time.Second
+	readTimeout       = 10 * time.Second
+	writeTimeout      = 10 * time.Second
+)
+
+// New initializes a Server with the provided configuration.
+func New(conf Config, ctrl Controller) *Server {
+	return &Server{conf, ctrl}
+}
+
+// ListenAndServe serves the API on the configured address. It blocks until
+// the server fails.
+func (s *Server) ListenAndServe() error {
+	slog.Info("starting control server", "address", s.conf.ListenAddress)
+	srv := &http.Server{
+		Addr:              s.conf.ListenAddress,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: readHeaderTimeout,
+		ReadTimeout:       readTimeout,
+		WriteTimeout:      writeTimeout,
+	}
+	return srv.ListenAndServe()
+}
+
+// Handler returns the http.Handler serving the API.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /next", s.command(s.ctrl.Next))
+	mux.HandleFunc("POST /prev", s.command(s.ctrl.Prev))
+	mux.HandleFunc("POST /pause", s.command(s.ctrl.Pause))
+	mux.HandleFunc("POST /resume", s.command(s.ctrl.Resume))
+	mux.HandleFunc("GET /current", s.current)
+	mux.HandleFunc("GET /history", s.history)
+	mux.HandleFunc("GET /planner", s.planner)
+	return mux
+}
+
+// command is a helper method to create a handler that sends a command to the
+// Controller. Commands are processed asynchronously, so the response is
+// 202 Accepted, or 503 Service Unavailable if the Controller could not queue
+// it.
+func (s *Server) command(f func() error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		slog.Debug("received control command", "path", r.URL.Path, "remote", r.RemoteAddr)
+		if err := f(); err != nil {
+			slog.Warn("failed to queue control command", "path", r.URL.Path, "error", err)
+			writeJSON(w, http.StatusServiceUnavailable, statusResponse{Status: err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusAccepted, statusResponse{Status: "accepted"})
+	}
+}
+
+// statusResponse is the response body for commands and errors.
+type statusResponse struct {
+	Status string `json:"status"`
+}
+
+// currentResponse is the response body for GET /current.
+type currentResponse struct {
+	Paused bool                 `json:"paused"`
+	Asset  immich.AssetMetadata `json:"asset"`
+	// Pair is the portrait image shown beside Asset, if any.
+	Pair *immich.AssetMetadata `json:"pair,omitempty"`
+}
+
+func (s *Server) current(w http.ResponseWriter, r *http.Request) {
+	md, pair, ok := s.ctrl.Current()
+	if !ok {
+		writeJSON(w, http.StatusNotFound, statusResponse{Status: "no asset displayed"})
+		return
+	}
+	writeJSON(w, http.StatusOK, currentResponse{
+		Paused: s.ctrl.Paused(),
+		Asset:  md,
+		Pair:   pair,
+	})
+}
+
+// historyResponse is the response body for GET /history.
+type historyResponse struct {
+	Index  int                    `json:"index"`
+	Assets []immich.AssetMetadata `json:"assets"`
+}
+
+func (s *Server) history(w http.ResponseWriter, r *http.Request) {
+	mds, index := s.ctrl.History()
+	if mds == nil {
+		mds = []immich.AssetMetadata{}
+	}
+	writeJSON(w, http.StatusOK, historyResponse{
+		Index:  index,
+		Assets: mds,
+	})
+}
+
+func (s *Server) planner(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.ctrl.PlannerStatus())
+}
+
+// writeJSON is a helper function to write v as the JSON response body.
+func writeJSON(w http.ResponseWriter, statusCode int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Debug("failed to write response", "error", err)
+	}
+}
@@ -0,0 +1,174 @@
+package server_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"immich-photo-frame/internal/app/controller/planners"
+	"immich-photo-frame/internal/app/server"
+	"immich-photo-frame/internal/immich"
+)
+
+var _ server.Controller = (*testController)(nil)
+
+// testController is a test implementation of [server.Controller] that records
+// the commands it receives.
+type testController struct {
+	cmds    []string
+	busy    bool
+	paused  bool
+	history []immich.AssetMetadata
+	index   int
+	pair    *immich.AssetMetadata
+}
+
+func (t *testController) Next() error   { return t.record("next") }
+func (t *testController) Prev() error   { return t.record("prev") }
+func (t *testController) Pause() error  { return t.record("pause") }
+func (t *testController) Resume() error { return t.record("resume") }
+func (t *testController) Paused() bool  { return t.paused }
+
+// record is a helper method to record the command, unless the controller is
+// busy.
+func (t *testController) record(cmd string) error {
+	if t.busy {
+		return errors.New("busy")
+	}
+	t.cmds = append(t.cmds, cmd)
+	return nil
+}
+
+func (t *testController) Current() (immich.AssetMetadata, *immich.AssetMetadata, bool) {
+	if len(t.history) == 0 {
+		return immich.AssetMetadata{}, nil, false
+	}
+	return t.history[t.index], t.pair, true
+}
+
+func (t *testController) History() ([]immich.AssetMetadata, int) {
+	return t.history, t.index
+}
+
+func (t *testController) PlannerStatus() planners.Status {
+	return planners.Status{Name: "shuffle", Position: 3, Total: 10}
+}
+
+// do is a helper function to make a request to the handler and decode the JSON
+// response into v.
+func do(t *testing.T, h http.Handler, method, path string, v any) int {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+	if v != nil {
+		if err := json.NewDecoder(rec.Body).Decode(v); err != nil {
+			t.Fatalf("failed to decode %s %s response: %v", method, path, err)
+		}
+	}
+	return rec.Code
+}
+
+func TestServerCommands(t *testing.T) {
+	ctrl := new(testController)
+	h := server.New(server.Config{}, ctrl).Handler()
+
+	for _, path := range []string{"/next", "/prev", "/pause", "/resume"} {
+		if code := do(t, h, http.MethodPost, path, nil); code != http.StatusAccepted {
+			t.Fatalf("POST %s: expected status %d, got %d", path, http.StatusAccepted, code)
+		}
+	}
+	expected := []string{"next", "prev", "pause", "resume"}
+	if len(ctrl.cmds) != len(expected) {
+		t.Fatalf("expected %d commands, got %d", len(expected), len(ctrl.cmds))
+	}
+	for i := range expected {
+		if ctrl.cmds[i] != expected[i] {
+			t.Fatalf("cmds[%d] should be %q, found %q", i, expected[i], ctrl.cmds[i])
+		}
+	}
+
+	if code := do(t, h, http.MethodGet, "/next", nil); code != http.StatusMethodNotAllowed {
+		t.Fatalf("GET /next: expected status %d, got %d", http.StatusMethodNotAllowed, code)
+	}
+}
+
+func TestServerCommandBusy(t *testing.T) {
+	ctrl := &testController{busy: true}
+	h := server.New(server.Config{}, ctrl).Handler()
+
+	var got struct {
+		Status string `json:"status"`
+	}
+	if code := do(t, h, http.MethodPost, "/next", &got); code != http.StatusServiceUnavailable {
+		t.Fatalf("expected status %d, got %d", http.StatusServiceUnavailable, code)
+	}
+	if got.Status != "busy" {
+		t.Fatalf(`expected status "busy", got %q`, got.Status)
+	}
+}
+
+func TestServerCurrent(t *testing.T) {
+	ctrl := new(testController)
+	h := server.New(server.Config{}, ctrl).Handler()
+
+	if code := do(t, h, http.MethodGet, "/current", nil); code != http.StatusNotFound {
+		t.Fatalf("expected status %d with no asset, got %d", http.StatusNotFound, code)
+	}
+
+	ctrl.history = []immich.AssetMetadata{{ID: "asset-1"}, {ID: "asset-2"}}
+	ctrl.index = 1
+	ctrl.paused = true
+	var got struct {
+		Paused bool                  `json:"paused"`
+		Asset  immich.AssetMetadata  `json:"asset"`
+		Pair   *immich.AssetMetadata `json:"pair"`
+	}
+	if code := do(t, h, http.MethodGet, "/current", &got); code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, code)
+	}
+	if !got.Paused || got.Asset.ID != "asset-2" || got.Pair != nil {
+		t.Fatalf(`expected paused "asset-2" without a pair, got %+v`, got)
+	}
+
+	ctrl.pair = &immich.AssetMetadata{ID: "asset-3"}
+	got.Pair = nil
+	if code := do(t, h, http.MethodGet, "/current", &got); code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, code)
+	}
+	if got.Pair == nil || got.Pair.ID != "asset-3" {
+		t.Fatalf(`expected "asset-3" to be paired, got %+v`, got.Pair)
+	}
+}
+
+func TestServerHistory(t *testing.T) {
+	ctrl := &testController{
+		history: []immich.AssetMetadata{{ID: "asset-1"}, {ID: "asset-2"}},
+		index:   1,
+	}
+	h := server.New(server.Config{}, ctrl).Handler()
+
+	var got struct {
+		Index  int                    `json:"index"`
+		Assets []immich.AssetMetadata `json:"assets"`
+	}
+	if code := do(t, h, http.MethodGet, "/history", &got); code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, code)
+	}
+	if got.Index != 1 || len(got.Assets) != 2 || got.Assets[0].ID != "asset-1" {
+		t.Fatalf("unexpected history: %+v", got)
+	}
+}
+
+func TestServerPlanner(t *testing.T) {
+	h := server.New(server.Config{}, new(testController)).Handler()
+
+	var got planners.Status
+	if code := do(t, h, http.MethodGet, "/planner", &got); code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, code)
+	}
+	if got.Name != "shuffle" || got.Position != 3 || got.Total != 10 {
+		t.Fatalf("unexpected planner status: %+v", got)
+	}
+}
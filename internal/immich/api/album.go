@@ -1,7 +1,7 @@
 package api
 
 import (
-	"encoding/json"
+	"context"
 	"path"
 	"time"
 )
@@ -26,18 +26,17 @@ type GetAlbumsResponse struct {
 	Albums       []Album
 }
 
-// GetAlbums retrieves all albums from the immich API.
-//
-// See: https://api.immich.app/endpoints/albums/getAllAlbums
+// GetAlbums is like [Client.GetAlbumsContext] without a context.
 func (c Client) GetAlbums() (*GetAlbumsResponse, error) {
-	resp, err := c.Get("/albums")
-	if err != nil {
-		return nil, err
-	}
+	return c.GetAlbumsContext(context.Background())
+}
 
-	defer resp.Body.Close()
+// GetAlbumsContext retrieves all albums from the immich API.
+//
+// See: https://api.immich.app/endpoints/albums/getAllAlbums
+func (c Client) GetAlbumsContext(ctx context.Context) (*GetAlbumsResponse, error) {
 	var albums []Album
-	if err := json.NewDecoder(resp.Body).Decode(&albums); err != nil {
+	if err := c.getJSON(ctx, "/albums", &albums); err != nil {
 		return nil, err
 	}
 	return &GetAlbumsResponse{
@@ -52,20 +51,21 @@ type GetAlbumsAssetsResponse struct {
 	AssetMetadatas []AssetMetadata
 }
 
-// GetAlbumAssets retrieves the album asset metadata for the provided album ID.
+// GetAlbumAssets is like [Client.GetAlbumAssetsContext] without a context.
+func (c Client) GetAlbumAssets(id AlbumID) (*GetAlbumsAssetsResponse, error) {
+	return c.GetAlbumAssetsContext(context.Background(), id)
+}
+
+// GetAlbumAssetsContext retrieves the album asset metadata for the provided
+// album ID.
 //
 // See: https://api.immich.app/endpoints/albums/getAlbumInfo
-func (c Client) GetAlbumAssets(id AlbumID) (*GetAlbumsAssetsResponse, error) {
-	resp, err := c.Get(path.Join("/albums", string(id)))
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
+func (c Client) GetAlbumAssetsContext(ctx context.Context, id AlbumID) (*GetAlbumsAssetsResponse, error) {
 	type albumResp struct {
 		Assets []AssetMetadata `json:"assets"`
 	}
 	var ar albumResp
-	if err := json.NewDecoder(resp.Body).Decode(&ar); err != nil {
+	if err := c.getJSON(ctx, path.Join("/albums", string(id)), &ar); err != nil {
 		return nil, err
 	}
 	return &GetAlbumsAssetsResponse{
@@ -1,9 +1,13 @@
 package api
 
 import (
-	"encoding/json"
-	"io"
+	"context"
+	"errors"
+	"fmt"
 	"path"
+	"slices"
+	"strings"
+	"time"
 
 	"fyne.io/fyne/v2"
 )
@@ -15,12 +19,15 @@ type AssetID string
 //
 // See: https://api.immich.app/endpoints/assets/getAssetInfo
 type AssetMetadata struct {
-	ID       AssetID          `json:"id"`
-	Type     string           `json:"type"`
-	Name     string           `json:"originalFileName"`
-	Duration string           `json:"duration"`
-	ExifInfo ExifInfo         `json:"exifInfo"`
-	People   []map[string]any `json:"people"`
+	ID       AssetID  `json:"id"`
+	Type     string   `json:"type"`
+	Name     string   `json:"originalFileName"`
+	Duration string   `json:"duration"`
+	ExifInfo ExifInfo `json:"exifInfo"`
+	People   []Person `json:"people"`
+	// LivePhotoVideoID is the ID of the hidden video asset with the motion
+	// of a Live Photo, if the asset is one.
+	LivePhotoVideoID AssetID `json:"livePhotoVideoId"`
 }
 
 // ExifInfo contains relevant EXIF data associated with an asset.
@@ -34,6 +41,32 @@ type ExifInfo struct {
 	TimeZone         string  `json:"timeZone"`
 	Latitude         float32 `json:"latitude"`
 	Longitude        float32 `json:"longitude"`
+	ExifImageWidth   int     `json:"exifImageWidth"`
+	ExifImageHeight  int     `json:"exifImageHeight"`
+	Orientation      string  `json:"orientation"`
+}
+
+// VideoDuration parses the Duration of a video asset, which immich formats as
+// "H:MM:SS.ffffff". It returns false if there is no duration.
+func (md AssetMetadata) VideoDuration() (time.Duration, bool) {
+	var h, m int
+	var s float64
+	if _, err := fmt.Sscanf(md.Duration, "%d:%d:%f", &h, &m, &s); err != nil {
+		return 0, false
+	}
+	d := time.Duration(h)*time.Hour + time.Duration(m)*time.Minute + time.Duration(s*float64(time.Second))
+	return d, d > 0
+}
+
+// Dimensions returns the width and height of the asset as it is displayed,
+// accounting for EXIF orientations that rotate the image by 90 degrees. Zeros
+// are returned if the dimensions are unknown.
+func (e ExifInfo) Dimensions() (int, int) {
+	switch e.Orientation {
+	case "5", "6", "7", "8":
+		return e.ExifImageHeight, e.ExifImageWidth
+	}
+	return e.ExifImageWidth, e.ExifImageHeight
 }
 
 // Asset implements fyne.Resource for displaying the asset.
@@ -41,58 +74,177 @@ var _ fyne.Resource = Asset{}
 
 // Asset combines AssetMetadata with the actual asset data.
 type Asset struct {
-	Meta AssetMetadata
-	Data []byte
+	Meta    AssetMetadata
+	Data    []byte
+	Quality AssetQuality
+}
+
+// AssetQuality is the rendition of an asset to download from immich.
+type AssetQuality string
+
+const (
+	// AssetQualityThumbnail is a small, heavily compressed image meant for
+	// the immich timeline.
+	AssetQualityThumbnail AssetQuality = "thumbnail"
+	// AssetQualityPreview is a medium-sized image, 1440 pixels on its
+	// shortest side by default.
+	AssetQualityPreview AssetQuality = "preview"
+	// AssetQualityFullsize is a full resolution image converted by immich
+	// to a format that browsers can show.
+	AssetQualityFullsize AssetQuality = "fullsize"
+	// AssetQualityOriginal is the originally uploaded file, which may be
+	// HEIC or RAW.
+	AssetQualityOriginal AssetQuality = "original"
+	// AssetQualityAuto is not an immich rendition. It asks the caller to
+	// pick one for the screen with [SmallestAssetQuality].
+	AssetQualityAuto AssetQuality = "auto"
+)
+
+// assetQualities is the list of all the AssetQualities that can be configured.
+var assetQualities = []AssetQuality{
+	AssetQualityAuto,
+	AssetQualityThumbnail,
+	AssetQualityPreview,
+	AssetQualityFullsize,
+	AssetQualityOriginal,
+}
+
+// The default length in pixels of the shortest side of the thumbnail and
+// preview renditions. They can be changed in the immich server's settings.
+const (
+	ThumbnailSize = 250
+	PreviewSize   = 1440
+)
+
+// SmallestAssetQuality returns the smallest immich rendition whose shortest
+// side is at least size pixels long, assuming the default rendition sizes.
+func SmallestAssetQuality(size int) AssetQuality {
+	switch {
+	case size <= ThumbnailSize:
+		return AssetQualityThumbnail
+	case size <= PreviewSize:
+		return AssetQualityPreview
+	default:
+		return AssetQualityFullsize
+	}
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (q *AssetQuality) UnmarshalText(text []byte) error {
+	quality := AssetQuality(strings.ToLower(string(text)))
+	if slices.Contains(assetQualities, quality) {
+		*q = quality
+		return nil
+	}
+	var validQualities []string
+	for _, quality := range assetQualities {
+		validQualities = append(validQualities, fmt.Sprintf("%q", quality))
+	}
+	return fmt.Errorf(
+		"unsupported asset quality %q, expected one of %v",
+		string(text), validQualities,
+	)
 }
 
 func (a Asset) Content() []byte { return a.Data }
 func (a Asset) Name() string    { return a.Meta.Name }
 
-// GetAssetPreview gets the metadata associated with an asset.
+// GetAssetPreview is like [Client.GetAssetPreviewContext] without a context.
+func (c Client) GetAssetPreview(id AssetID) (*AssetMetadata, error) {
+	return c.GetAssetPreviewContext(context.Background(), id)
+}
+
+// GetAssetPreviewContext gets the metadata associated with an asset.
 //
 // See: https://api.immich.app/endpoints/assets/getAssetInfo
-func (c Client) GetAssetPreview(id AssetID) (*AssetMetadata, error) {
-	// Get asset metadata.
-	resp, err := c.Get(path.Join("/assets", string(id)))
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
+func (c Client) GetAssetPreviewContext(ctx context.Context, id AssetID) (*AssetMetadata, error) {
 	var md AssetMetadata
-	if err := json.NewDecoder(resp.Body).Decode(&md); err != nil {
+	if err := c.getJSON(ctx, path.Join("/assets", string(id)), &md); err != nil {
 		return nil, err
 	}
 	return &md, nil
 }
 
-// GetAsset gets the asset associated with the metadata.
+// GetAsset is like [Client.GetAssetContext] without a context.
+func (c Client) GetAsset(md AssetMetadata, quality AssetQuality) (*Asset, error) {
+	return c.GetAssetContext(context.Background(), md, quality)
+}
+
+// GetAssetContext gets the asset associated with the metadata in the requested
+// quality. Only images have fullsize and original renditions, so other assets
+// use the preview quality instead. An empty quality is the preview quality.
 //
-// See: https://api.immich.app/endpoints/assets/viewAsset
-func (c Client) GetAsset(md AssetMetadata) (*Asset, error) {
-	p := path.Join("/assets", string(md.ID), "thumbnail")
-	resp, err := c.Get(p + "?size=preview")
-	if err != nil {
-		return nil, err
+// See: https://api.immich.app/endpoints/assets/viewAsset and
+// https://api.immich.app/endpoints/assets/downloadAsset
+func (c Client) GetAssetContext(ctx context.Context, md AssetMetadata, quality AssetQuality) (*Asset, error) {
+	if quality == AssetQualityAuto {
+		return nil, errors.New("the auto asset quality must be resolved to an immich rendition")
 	}
-
-	defer resp.Body.Close()
-	data, err := io.ReadAll(resp.Body)
+	if quality == "" {
+		quality = AssetQualityPreview
+	}
+	rendition := quality
+	if md.Type != "IMAGE" && rendition != AssetQualityThumbnail {
+		rendition = AssetQualityPreview
+	}
+	p := path.Join("/assets", string(md.ID), "thumbnail") + "?size=" + string(rendition)
+	if rendition == AssetQualityOriginal {
+		p = path.Join("/assets", string(md.ID), "original")
+	}
+	data, err := c.getBytes(ctx, p)
 	if err != nil {
 		return nil, err
 	}
 
 	return &Asset{
-		Meta: md,
-		Data: data,
+		Meta:    md,
+		Data:    data,
+		Quality: quality,
 	}, nil
 }
 
-// GetAssetByID retrieves the requested asset along with its metadata. This
-// method is a convenience method for calling [GetAssetPreview] and [GetAsset].
-func (c Client) GetAssetByID(id AssetID) (*Asset, error) {
-	md, err := c.GetAssetPreview(id)
+// GetVideo is like [Client.GetVideoContext] without a context.
+func (c Client) GetVideo(id AssetID) ([]byte, error) {
+	return c.GetVideoContext(context.Background(), id)
+}
+
+// GetVideoContext gets the video of a video asset, transcoded by immich for
+// playback.
+//
+// See: https://api.immich.app/endpoints/assets/playAssetVideo
+func (c Client) GetVideoContext(ctx context.Context, id AssetID) ([]byte, error) {
+	return c.getBytes(ctx, path.Join("/assets", string(id), "video", "playback"))
+}
+
+// GetLivePhotoVideo is like [Client.GetLivePhotoVideoContext] without a
+// context.
+func (c Client) GetLivePhotoVideo(md AssetMetadata) ([]byte, error) {
+	return c.GetLivePhotoVideoContext(context.Background(), md)
+}
+
+// GetLivePhotoVideoContext gets the motion video of a Live Photo, transcoded by
+// immich for playback. An error is returned if the asset is not a Live Photo.
+//
+// See: https://api.immich.app/endpoints/assets/playAssetVideo
+func (c Client) GetLivePhotoVideoContext(ctx context.Context, md AssetMetadata) ([]byte, error) {
+	if md.LivePhotoVideoID == "" {
+		return nil, errors.New("asset is not a live photo")
+	}
+	return c.GetVideoContext(ctx, md.LivePhotoVideoID)
+}
+
+// GetAssetByID is like [Client.GetAssetByIDContext] without a context.
+func (c Client) GetAssetByID(id AssetID, quality AssetQuality) (*Asset, error) {
+	return c.GetAssetByIDContext(context.Background(), id, quality)
+}
+
+// GetAssetByIDContext retrieves the requested asset along with its metadata.
+// This method is a convenience method for calling [GetAssetPreviewContext] and
+// [GetAssetContext].
+func (c Client) GetAssetByIDContext(ctx context.Context, id AssetID, quality AssetQuality) (*Asset, error) {
+	md, err := c.GetAssetPreviewContext(ctx, id)
 	if err != nil {
 		return nil, err
 	}
-	return c.GetAsset(*md)
+	return c.GetAssetContext(ctx, *md, quality)
 }
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestVideoDuration(t *testing.T) {
+	for _, tt := range []struct {
+		duration string
+		expected time.Duration
+		ok       bool
+	}{
+		{"0:00:12.345000", 12345 * time.Millisecond, true},
+		{"1:02:03.000000", time.Hour + 2*time.Minute + 3*time.Second, true},
+		{"0:00:00.00000", 0, false},
+		{"", 0, false},
+		{"12 seconds", 0, false},
+	} {
+		d, ok := AssetMetadata{Duration: tt.duration}.VideoDuration()
+		if d != tt.expected || ok != tt.ok {
+			t.Errorf("VideoDuration() of %q = %v, %t, want %v, %t", tt.duration, d, ok, tt.expected, tt.ok)
+		}
+	}
+}
+
+func TestLivePhotoVideoID(t *testing.T) {
+	var md AssetMetadata
+	err := json.Unmarshal([]byte(`{
+		"id": "a5f3c2e0-0000-4000-8000-000000000001",
+		"type": "IMAGE",
+		"originalFileName": "IMG_0001.HEIC",
+		"livePhotoVideoId": "a5f3c2e0-0000-4000-8000-000000000002"
+	}`), &md)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if md.LivePhotoVideoID != "a5f3c2e0-0000-4000-8000-000000000002" {
+		t.Errorf("unexpected live photo video ID %q", md.LivePhotoVideoID)
+	}
+	if _, err := (Client{}).GetLivePhotoVideo(AssetMetadata{ID: md.ID}); err == nil {
+		t.Error("expected an error getting the video of an asset that is not a live photo")
+	}
+}
+
+func TestSmallestAssetQuality(t *testing.T) {
+	for size, expected := range map[int]AssetQuality{
+		200:  AssetQualityThumbnail,
+		250:  AssetQualityThumbnail,
+		1080: AssetQualityPreview,
+		1440: AssetQualityPreview,
+		2160: AssetQualityFullsize,
+	} {
+		if got := SmallestAssetQuality(size); got != expected {
+			t.Errorf("SmallestAssetQuality(%d) = %q, want %q", size, got, expected)
+		}
+	}
+}
@@ -1,16 +1,28 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"path"
+	"time"
 )
 
+// maxRetryDelay caps the delay between retries of a failed request.
+const maxRetryDelay = 10 * time.Second
+
+// defaultRetryDelay is the delay before the first retry of a failed request if
+// Config.RetryDelay is not set.
+const defaultRetryDelay = 500 * time.Millisecond
+
 // Client provides a raw HTTP client for accessing the immich API. All requests
 // will get rewritten to the API endpoint with authorization, so only the path
 // is required for requests.
@@ -23,6 +35,8 @@ import (
 // ```
 type Client struct {
 	*http.Client
+	maxRetries int
+	retryDelay time.Duration
 }
 
 // Config holds configuration values for configuring the immich client.
@@ -35,6 +49,15 @@ type Config struct {
 	// ImmichAPIKey should ideally not be written to disk un-encrypted,
 	// however, for ease of "deployment" I'm going to allow it.
 	ImmichAPIKey string
+	// RequestTimeout limits how long a single request, including reading its
+	// response, may take. Zero means no timeout.
+	RequestTimeout time.Duration
+	// MaxRetries is how many times a request is retried after a server or
+	// network error, with exponential backoff between attempts.
+	MaxRetries int
+	// RetryDelay is the delay before the first retry of a failed request,
+	// doubling with each retry after. Zero uses a default of 500ms.
+	RetryDelay time.Duration
 }
 
 // HydrateFromEnv overwrites any values in Config with their associated
@@ -98,38 +121,149 @@ func NewClient(conf Config) Client {
 			r.URL = &immichAPI
 		},
 	}
-	return Client{&http.Client{Transport: transport}}
+	retryDelay := conf.RetryDelay
+	if retryDelay <= 0 {
+		retryDelay = defaultRetryDelay
+	}
+	return Client{
+		Client:     &http.Client{Transport: transport, Timeout: conf.RequestTimeout},
+		maxRetries: conf.MaxRetries,
+		retryDelay: retryDelay,
+	}
 }
 
-// IsConnected performs a sanity check API request to /users/me to verify the
-// Client is configured correctly and the immich server is responsive.
+// IsConnected is like [Client.IsConnectedContext] without a context.
 func (c Client) IsConnected() error {
-	resp, err := c.Get("/users/me")
+	return c.IsConnectedContext(context.Background())
+}
+
+// IsConnectedContext performs a sanity check API request to /users/me to
+// verify the Client is configured correctly and the immich server is
+// responsive.
+func (c Client) IsConnectedContext(ctx context.Context) error {
+	// Check it's a JSON response.
+	var m map[string]any
+	err := c.getJSON(ctx, "/users/me", &m)
 	if err != nil && err.Error() == `Get "/users/me": unsupported protocol scheme ""` {
 		return errors.New("misconfigured client: missing immich endpoint")
-	} else if err != nil {
+	}
+	return err
+}
+
+// getJSON is a helper method to GET the path and decode its JSON response
+// into v.
+func (c Client) getJSON(ctx context.Context, p string, v any) error {
+	return c.get(ctx, p, func(r io.Reader) error {
+		return json.NewDecoder(r).Decode(v)
+	})
+}
+
+// getBytes is a helper method to GET the path and read its whole response.
+func (c Client) getBytes(ctx context.Context, p string) ([]byte, error) {
+	var data []byte
+	err := c.get(ctx, p, func(r io.Reader) error {
+		var err error
+		data, err = io.ReadAll(r)
 		return err
+	})
+	return data, err
+}
+
+// get is a helper method to GET the path and pass its response body to read.
+// Requests failing with an error that [IsUnavailable] reports are retried up
+// to the Client's MaxRetries, with exponential backoff and jitter in between,
+// until ctx is done.
+func (c Client) get(ctx context.Context, p string, read func(io.Reader) error) error {
+	for attempt := 0; ; attempt++ {
+		err := c.getOnce(ctx, p, read)
+		if err == nil || attempt >= c.maxRetries || !IsUnavailable(err) || ctx.Err() != nil {
+			return err
+		}
+		delay := retryDelay(c.retryDelay, attempt)
+		slog.Debug("retrying immich request", "path", p, "attempt", attempt+1, "delay", delay, "error", err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
 	}
-	defer resp.Body.Close()
-	// Check the response code.
-	if err := checkStatusCode(resp.StatusCode); err != nil {
+}
+
+// getOnce is a helper method to make a single attempt at a GET request for
+// [Client.get].
+func (c Client) getOnce(ctx context.Context, p string, read func(io.Reader) error) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p, nil)
+	if err != nil {
 		return err
 	}
-	// Check it's a JSON response.
-	var m map[string]any
-	if err := json.NewDecoder(resp.Body).Decode(&m); err != nil {
+	resp, err := c.Do(req)
+	if err != nil {
 		return err
 	}
-	return nil
+	defer resp.Body.Close()
+	return read(resp.Body)
+}
+
+// retryDelay is a helper function to get the delay before the retry after the
+// given attempt, starting from base. The delay doubles with each attempt up to
+// maxRetryDelay, and is jittered to between half and all of it so clients
+// recovering from the same outage don't retry in lockstep.
+func retryDelay(base time.Duration, attempt int) time.Duration {
+	delay := maxRetryDelay
+	if attempt < 16 {
+		delay = min(base<<attempt, maxRetryDelay)
+	}
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// StatusError is returned when the immich API responds with a status code
+// other than 200 OK.
+type StatusError struct {
+	StatusCode int
+}
+
+func (e StatusError) Error() string {
+	if e.StatusCode == http.StatusUnauthorized {
+		return "invalid immich token"
+	}
+	return fmt.Sprintf("unexpected status code %d", e.StatusCode)
+}
+
+// IsUnavailable reports whether err means the immich server could not be
+// reached or could not handle the request right now, such as a network error,
+// a timeout, or a 5xx or 429 status code, so the request may succeed if it is
+// tried again later. Errors from a canceled request are not considered
+// unavailable.
+func IsUnavailable(err error) bool {
+	var statusErr StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= http.StatusInternalServerError ||
+			statusErr.StatusCode == http.StatusTooManyRequests
+	}
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	// url.Error implements net.Error itself, so check what it wraps. A server
+	// closing the connection before responding surfaces as io.EOF.
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		if errors.Is(urlErr.Err, io.EOF) {
+			return true
+		}
+		err = urlErr.Err
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
 }
 
 // checkStatusCode is a helper function to check for a 200 OK status
 // code and return a descriptive error if not.
 func checkStatusCode(statusCode int) error {
-	if statusCode == http.StatusUnauthorized {
-		return errors.New("invalid immich token")
-	} else if statusCode != http.StatusOK {
-		return fmt.Errorf("unexpected status code %d", statusCode)
+	if statusCode != http.StatusOK {
+		return StatusError{StatusCode: statusCode}
 	}
 	return nil
 }
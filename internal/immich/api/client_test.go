@@ -0,0 +1,130 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testServer is a helper function to start a server responding with handler
+// and a Client for it.
+func testServer(t *testing.T, conf Config, handler http.HandlerFunc) Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	conf.ImmichAPIEndpoint = srv.URL
+	conf.RetryDelay = time.Millisecond
+	return NewClient(conf)
+}
+
+func TestClientRetriesUnavailable(t *testing.T) {
+	var requests atomic.Int32
+	c := testServer(t, Config{MaxRetries: 2}, func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`[{"id": "album-1"}]`))
+	})
+	resp, err := c.GetAlbums()
+	if err != nil {
+		t.Fatalf("expected the third attempt to succeed, got error: %v", err)
+	}
+	if len(resp.Albums) != 1 || resp.Albums[0].ID != "album-1" {
+		t.Errorf("unexpected albums %+v", resp.Albums)
+	}
+	if n := requests.Load(); n != 3 {
+		t.Errorf("expected 3 requests, got %d", n)
+	}
+}
+
+func TestClientGivesUpAfterMaxRetries(t *testing.T) {
+	var requests atomic.Int32
+	c := testServer(t, Config{MaxRetries: 2}, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	_, err := c.GetAlbums()
+	var statusErr StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusBadGateway {
+		t.Fatalf("expected a 502 status error, got %v", err)
+	}
+	if !IsUnavailable(err) {
+		t.Errorf("expected %v to be unavailable", err)
+	}
+	if n := requests.Load(); n != 3 {
+		t.Errorf("expected 3 requests, got %d", n)
+	}
+}
+
+func TestClientDoesNotRetryClientErrors(t *testing.T) {
+	var requests atomic.Int32
+	c := testServer(t, Config{MaxRetries: 2}, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+	})
+	_, err := c.GetAssetPreview("asset-1")
+	if err == nil || IsUnavailable(err) {
+		t.Fatalf("expected an error that is not unavailable, got %v", err)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("expected 1 request, got %d", n)
+	}
+}
+
+func TestClientRequestTimeout(t *testing.T) {
+	var requests atomic.Int32
+	c := testServer(t, Config{RequestTimeout: 50 * time.Millisecond, MaxRetries: 1}, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	})
+	start := time.Now()
+	_, err := c.GetVideo("asset-1")
+	if err == nil || !IsUnavailable(err) {
+		t.Fatalf("expected a timeout error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("expected the request to time out, took %v", elapsed)
+	}
+	if n := requests.Load(); n != 2 {
+		t.Errorf("expected 2 requests, got %d", n)
+	}
+}
+
+func TestClientContextCanceled(t *testing.T) {
+	var requests atomic.Int32
+	c := testServer(t, Config{MaxRetries: 5}, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.GetAlbumsContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected a canceled error, got %v", err)
+	}
+	if n := requests.Load(); n != 0 {
+		t.Errorf("expected no requests, got %d", n)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	for attempt, want := range []time.Duration{
+		500 * time.Millisecond, time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, maxRetryDelay, maxRetryDelay,
+	} {
+		for range 100 {
+			if d := retryDelay(defaultRetryDelay, attempt); d < want/2 || d > want {
+				t.Fatalf("retryDelay(%d) = %v, want between %v and %v", attempt, d, want/2, want)
+			}
+		}
+	}
+	if d := retryDelay(defaultRetryDelay, 100); d > maxRetryDelay {
+		t.Errorf("retryDelay(100) = %v, want at most %v", d, maxRetryDelay)
+	}
+}
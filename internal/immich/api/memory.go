@@ -0,0 +1,53 @@
+package api
+
+import (
+	"context"
+	"net/url"
+	"time"
+)
+
+// Memory is a collection of assets that immich groups together, such as
+// the assets taken on this day in previous years.
+//
+// See: https://api.immich.app/models/MemoryResponseDto
+type Memory struct {
+	ID     string          `json:"id"`
+	Type   string          `json:"type"`
+	Data   MemoryData      `json:"data"`
+	Assets []AssetMetadata `json:"assets"`
+}
+
+// MemoryData contains the type-specific data of a Memory.
+type MemoryData struct {
+	// Year is the year the assets of an "on_this_day" memory are from.
+	Year int `json:"year"`
+}
+
+// GetMemoriesResponse wraps the immich API response with some metadata.
+type GetMemoriesResponse struct {
+	ResponseTime time.Time
+	Memories     []Memory
+}
+
+// GetMemories is like [Client.GetMemoriesContext] without a context.
+func (c Client) GetMemories(day time.Time) (*GetMemoriesResponse, error) {
+	return c.GetMemoriesContext(context.Background(), day)
+}
+
+// GetMemoriesContext retrieves the "on this day" memories for the provided day
+// from the immich API.
+//
+// See: https://api.immich.app/endpoints/memories/searchMemories
+func (c Client) GetMemoriesContext(ctx context.Context, day time.Time) (*GetMemoriesResponse, error) {
+	query := url.Values{}
+	query.Set("for", day.Format(time.RFC3339))
+	query.Set("type", "on_this_day")
+	var memories []Memory
+	if err := c.getJSON(ctx, "/memories?"+query.Encode(), &memories); err != nil {
+		return nil, err
+	}
+	return &GetMemoriesResponse{
+		ResponseTime: time.Now(),
+		Memories:     memories,
+	}, nil
+}
@@ -0,0 +1,40 @@
+package api
+
+// PersonID is the immich ID for a person, usually in the shape of UUIDv4.
+type PersonID string
+
+// Person contains relevant information about a person detected in an asset.
+//
+// See: https://api.immich.app/models/PersonWithFacesResponseDto
+type Person struct {
+	ID       PersonID `json:"id"`
+	Name     string   `json:"name"`
+	IsHidden bool     `json:"isHidden"`
+	Faces    []Face   `json:"faces"`
+}
+
+// Face contains the bounding box of a person's face in an asset. The bounding
+// box is in pixels of an image that is ImageWidth by ImageHeight, which may
+// not be the size of the original asset.
+//
+// See: https://api.immich.app/models/AssetFaceWithoutPersonResponseDto
+type Face struct {
+	ID            string `json:"id"`
+	ImageWidth    int    `json:"imageWidth"`
+	ImageHeight   int    `json:"imageHeight"`
+	BoundingBoxX1 int    `json:"boundingBoxX1"`
+	BoundingBoxY1 int    `json:"boundingBoxY1"`
+	BoundingBoxX2 int    `json:"boundingBoxX2"`
+	BoundingBoxY2 int    `json:"boundingBoxY2"`
+}
+
+// Region returns the normalized bounding box (0 to 1 on each axis) of the
+// face. It returns false if the face has no image dimensions.
+func (f Face) Region() (x1, y1, x2, y2 float32, ok bool) {
+	if f.ImageWidth <= 0 || f.ImageHeight <= 0 {
+		return 0, 0, 0, 0, false
+	}
+	w, h := float32(f.ImageWidth), float32(f.ImageHeight)
+	return float32(f.BoundingBoxX1) / w, float32(f.BoundingBoxY1) / h,
+		float32(f.BoundingBoxX2) / w, float32(f.BoundingBoxY2) / h, true
+}
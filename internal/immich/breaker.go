@@ -0,0 +1,133 @@
+package immich
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"immich-photo-frame/internal/immich/api"
+)
+
+const (
+	// breakerThreshold is how many consecutive failed requests open the
+	// circuit breaker.
+	breakerThreshold = 3
+	// breakerCooldown is how long an open circuit breaker fails requests
+	// before letting one through to check if the server is back.
+	breakerCooldown = 30 * time.Second
+)
+
+// errBreakerOpen is returned instead of making a request while the circuit
+// breaker is open.
+var errBreakerOpen = errors.New("immich server unavailable, skipping request")
+
+// circuitBreaker tracks consecutive failed requests to the immich server. Once
+// breakerThreshold requests in a row fail because the server is unavailable,
+// the breaker opens and requests fail immediately, so the Client falls back to
+// local storage instead of waiting on timeouts and retries. After
+// breakerCooldown, a single request is let through, closing the breaker if it
+// succeeds or opening it for another cooldown if it fails.
+type circuitBreaker struct {
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	probing  bool
+	now      func() time.Time
+}
+
+func newCircuitBreaker() *circuitBreaker {
+	return &circuitBreaker{now: time.Now}
+}
+
+// allow reports whether a request should be made.
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures < breakerThreshold {
+		return true
+	}
+	if b.probing || b.now().Sub(b.openedAt) < breakerCooldown {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+// record updates the breaker with the result of a request that [allow]
+// permitted. Only errors where the server is unavailable count as failures,
+// since other errors, such as a missing asset, mean the server is responding.
+// Canceled requests say nothing about the server, so they leave the failures
+// as they are.
+func (b *circuitBreaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+	if err != nil && api.IsUnavailable(err) {
+		b.failures++
+		if b.failures >= breakerThreshold {
+			if b.failures == breakerThreshold {
+				slog.Warn("immich server unavailable, using local storage", "error", err, "cooldown", breakerCooldown.String())
+			}
+			b.openedAt = b.now()
+		}
+		return
+	}
+	if b.failures >= breakerThreshold {
+		slog.Info("immich server available again")
+	}
+	b.failures = 0
+}
+
+// breakerClient wraps a remoteClient with a circuitBreaker.
+type breakerClient struct {
+	remote  remoteClient
+	breaker *circuitBreaker
+}
+
+// withBreaker is a helper function to make the request f through the circuit
+// breaker.
+func withBreaker[T any](b *circuitBreaker, f func() (T, error)) (T, error) {
+	if !b.allow() {
+		var zero T
+		return zero, errBreakerOpen
+	}
+	v, err := f()
+	b.record(err)
+	return v, err
+}
+
+func (c breakerClient) IsConnected() error {
+	_, err := withBreaker(c.breaker, func() (struct{}, error) {
+		return struct{}{}, c.remote.IsConnected()
+	})
+	return err
+}
+
+func (c breakerClient) GetMemories(day time.Time) (*GetMemoriesResponse, error) {
+	return withBreaker(c.breaker, func() (*GetMemoriesResponse, error) { return c.remote.GetMemories(day) })
+}
+
+func (c breakerClient) GetAssetPreview(id AssetID) (*AssetMetadata, error) {
+	return withBreaker(c.breaker, func() (*AssetMetadata, error) { return c.remote.GetAssetPreview(id) })
+}
+
+func (c breakerClient) GetVideo(id AssetID) ([]byte, error) {
+	return withBreaker(c.breaker, func() ([]byte, error) { return c.remote.GetVideo(id) })
+}
+
+func (c breakerClient) GetAsset(md AssetMetadata, quality AssetQuality) (*Asset, error) {
+	return withBreaker(c.breaker, func() (*Asset, error) { return c.remote.GetAsset(md, quality) })
+}
+
+func (c breakerClient) GetAlbums() (*GetAlbumsResponse, error) {
+	return withBreaker(c.breaker, c.remote.GetAlbums)
+}
+
+func (c breakerClient) GetAlbumAssets(id AlbumID) (*GetAlbumAssetsResponse, error) {
+	return withBreaker(c.breaker, func() (*GetAlbumAssetsResponse, error) { return c.remote.GetAlbumAssets(id) })
+}
@@ -0,0 +1,122 @@
+package immich
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"immich-photo-frame/internal/immich/api"
+)
+
+// fakeRemote is a remoteClient whose albums requests fail with err.
+type fakeRemote struct {
+	noopClient
+	requests *int
+	err      error
+}
+
+func (f fakeRemote) GetAlbums() (*GetAlbumsResponse, error) {
+	*f.requests++
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &GetAlbumsResponse{Albums: []Album{{ID: "album-1"}}}, nil
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Now()
+	breaker := newCircuitBreaker()
+	breaker.now = func() time.Time { return now }
+	var requests int
+	remote := &fakeRemote{requests: &requests, err: api.StatusError{StatusCode: http.StatusServiceUnavailable}}
+	c := breakerClient{remote: remote, breaker: breaker}
+
+	for range breakerThreshold {
+		if _, err := c.GetAlbums(); errors.Is(err, errBreakerOpen) {
+			t.Fatal("expected the breaker to be closed")
+		}
+	}
+	if _, err := c.GetAlbums(); !errors.Is(err, errBreakerOpen) {
+		t.Fatalf("expected the breaker to be open, got %v", err)
+	}
+	if requests != breakerThreshold {
+		t.Errorf("expected %d requests, got %d", breakerThreshold, requests)
+	}
+
+	// A failed probe after the cooldown opens the breaker again.
+	now = now.Add(breakerCooldown)
+	if _, err := c.GetAlbums(); errors.Is(err, errBreakerOpen) {
+		t.Fatal("expected a probe request after the cooldown")
+	}
+	if _, err := c.GetAlbums(); !errors.Is(err, errBreakerOpen) {
+		t.Fatalf("expected the breaker to be open after a failed probe, got %v", err)
+	}
+
+	// A successful probe closes the breaker.
+	now = now.Add(breakerCooldown)
+	remote.err = nil
+	for range 2 {
+		if _, err := c.GetAlbums(); err != nil {
+			t.Fatalf("expected the breaker to be closed, got %v", err)
+		}
+	}
+	if requests != breakerThreshold+3 {
+		t.Errorf("expected %d requests, got %d", breakerThreshold+3, requests)
+	}
+}
+
+func TestCircuitBreakerIgnoresClientErrors(t *testing.T) {
+	var requests int
+	c := breakerClient{
+		remote:  fakeRemote{requests: &requests, err: api.StatusError{StatusCode: http.StatusNotFound}},
+		breaker: newCircuitBreaker(),
+	}
+	for range breakerThreshold + 1 {
+		if _, err := c.GetAlbums(); errors.Is(err, errBreakerOpen) {
+			t.Fatal("expected the breaker to stay closed")
+		}
+	}
+}
+
+func TestClientFallsBackToLocalStorageWhenBreakerOpen(t *testing.T) {
+	now := time.Now()
+	breaker := newCircuitBreaker()
+	breaker.now = func() time.Time { return now }
+	var requests int
+	local := newTestLocalStorageClient(t, t.TempDir(), 1000)
+	c := NewClient(WithRefreshInterval(time.Hour))
+	c.local = local
+	c.remote = breakerClient{
+		remote:  fakeRemote{requests: &requests, err: api.StatusError{StatusCode: http.StatusBadGateway}},
+		breaker: breaker,
+	}
+	stale := GetAlbumsResponse{Albums: []Album{{ID: "album-1"}}, ResponseTime: now.Add(-2 * time.Hour)}
+	if err := local.StoreAlbums(stale); err != nil {
+		t.Fatal(err)
+	}
+	for range 2 * breakerThreshold {
+		albums, err := c.GetAlbums()
+		if err != nil || len(albums) != 1 {
+			t.Fatalf("expected the stale albums from local storage, got %v, %v", albums, err)
+		}
+	}
+	if requests != breakerThreshold {
+		t.Errorf("expected %d requests, got %d", breakerThreshold, requests)
+	}
+}
+
+func TestCircuitBreakerIgnoresCanceled(t *testing.T) {
+	breaker := newCircuitBreaker()
+	unavailable := api.StatusError{StatusCode: http.StatusServiceUnavailable}
+	for range breakerThreshold - 1 {
+		breaker.record(unavailable)
+	}
+	breaker.record(fmt.Errorf("request: %w", context.Canceled))
+	breaker.record(unavailable)
+	if breaker.allow() {
+		t.Fatal("expected a canceled request to keep the failures counted")
+	}
+}
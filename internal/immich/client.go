@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/dustin/go-humanize"
@@ -30,7 +31,7 @@ type rwClient interface {
 
 // readClient is a client that can provide immich albums and assets.
 type readClient interface {
-	GetAsset(md AssetMetadata) (*Asset, error)
+	GetAsset(md AssetMetadata, quality AssetQuality) (*Asset, error)
 	GetAlbums() (*GetAlbumsResponse, error)
 	GetAlbumAssets(id AlbumID) (*GetAlbumAssetsResponse, error)
 }
@@ -42,19 +43,54 @@ type writeClient interface {
 	StoreAlbumAssets(id AlbumID, resp GetAlbumAssetsResponse) error
 }
 
+// stateClient is a client that can store and retrieve saved state. Only local
+// storage implements it, since state needs to persist across restarts.
+type stateClient interface {
+	GetState(name string) ([]byte, error)
+	StoreState(name string, data []byte) error
+}
+
+// assetMetadataClient is a client that can store and retrieve the metadata of
+// individual assets. Only local storage implements it, so the in-memory cache
+// stays small for large libraries.
+type assetMetadataClient interface {
+	GetAssetMetadata(id AssetID) (*assetMetadataResponse, error)
+	StoreAssetMetadata(resp assetMetadataResponse) error
+}
+
+// assetMetadataResponse is the metadata of a single asset with the time it was
+// fetched, so it can be refreshed like album metadata. The metadata is
+// embedded, so metadata stored without a ResponseTime is treated as stale.
+type assetMetadataResponse struct {
+	ResponseTime time.Time
+	AssetMetadata
+}
+
+// videoClient is a client that can store the videos of video assets and
+// provide the path of their files. Only local storage implements it, since
+// videos are too large to keep in memory.
+type videoClient interface {
+	VideoPath(id AssetID) (string, error)
+	StoreVideo(id AssetID, data []byte) error
+}
+
 // remoteClient is a read-only client with a connection check.
 type remoteClient interface {
 	IsConnected() error
+	GetMemories(day time.Time) (*GetMemoriesResponse, error)
+	GetAssetPreview(id AssetID) (*AssetMetadata, error)
+	GetVideo(id AssetID) ([]byte, error)
 	readClient
 }
 
-// GetAsset retrieves an immich asset given its metadata. It first checks the
-// in-memory cache, then local storage, then the remote server. On success, the
-// in-memory cache and (if applicable) the local storage are updated.
-func (c Client) GetAsset(md AssetMetadata) (*Asset, error) {
-	log := slog.With("id", md.ID, "name", md.Name)
+// GetAsset retrieves an immich asset given its metadata and quality. It first
+// checks the in-memory cache, then local storage, then the remote server. On
+// success, the in-memory cache and (if applicable) the local storage are
+// updated. Each quality is stored separately.
+func (c Client) GetAsset(md AssetMetadata, quality AssetQuality) (*Asset, error) {
+	log := slog.With("id", md.ID, "name", md.Name, "quality", quality)
 	{
-		ass, err := c.cache.GetAsset(md)
+		ass, err := c.cache.GetAsset(md, quality)
 		if err == nil {
 			log.Debug("found asset in cache", "size", humanize.Bytes(uint64(len(ass.Data))))
 			return ass, nil
@@ -62,7 +98,7 @@ func (c Client) GetAsset(md AssetMetadata) (*Asset, error) {
 		log.Debug("failed to get asset from cache", "error", err)
 	}
 	{
-		ass, err := c.local.GetAsset(md)
+		ass, err := c.local.GetAsset(md, quality)
 		if err == nil {
 			log.Debug("found asset in local storage", "size", humanize.Bytes(uint64(len(ass.Data))))
 			log.Debug("storing asset in cache", "error", c.cache.StoreAsset(ass))
@@ -72,7 +108,7 @@ func (c Client) GetAsset(md AssetMetadata) (*Asset, error) {
 	}
 	{
 		log.Debug("fetching asset from remote")
-		ass, err := c.remote.GetAsset(md)
+		ass, err := c.remote.GetAsset(md, quality)
 		if err == nil {
 			log.Info("fetched asset from remote", "size", humanize.Bytes(uint64(len(ass.Data))))
 			log.Debug("storing asset in cache", "error", c.cache.StoreAsset(ass))
@@ -147,10 +183,24 @@ func (c Client) GetAlbums() ([]Album, error) {
 // server. On success, the in-memory cache and (if-applicable) the local
 // storage are updates.
 func (c Client) GetAlbumAssets(id AlbumID) ([]AssetMetadata, error) {
+	return c.getAlbumAssets(id, c.cache)
+}
+
+// GetAlbumAssetsUncached gets the asset metadata for the given immich album ID
+// like [Client.GetAlbumAssets], but skips the in-memory cache, so planners that
+// only keep part of the metadata don't keep all of it in memory through the
+// cache. Local storage is still checked and updated.
+func (c Client) GetAlbumAssetsUncached(id AlbumID) ([]AssetMetadata, error) {
+	return c.getAlbumAssets(id, noopClient{})
+}
+
+// getAlbumAssets is a helper method to get the asset metadata for the given
+// album using the provided in-memory cache, see [Client.GetAlbumAssets].
+func (c Client) getAlbumAssets(id AlbumID, cache rwClient) ([]AssetMetadata, error) {
 	log := slog.With("id", id)
 	var foundResp *GetAlbumAssetsResponse
 	{
-		resp, err := c.cache.GetAlbumAssets(id)
+		resp, err := cache.GetAlbumAssets(id)
 		if err == nil && !c.shouldRefresh(resp.ResponseTime) {
 			log.Debug("found album asset metadata in cache",
 				"age", time.Since(resp.ResponseTime).String(),
@@ -171,7 +221,7 @@ func (c Client) GetAlbumAssets(id AlbumID) ([]AssetMetadata, error) {
 			log.Debug("found album asset metadata in local storage",
 				"age", time.Since(resp.ResponseTime).String(),
 				"maxAge", c.refreshInterval.String())
-			log.Debug("storing album asset metadata in cache", "error", c.cache.StoreAlbumAssets(id, *resp))
+			log.Debug("storing album asset metadata in cache", "error", cache.StoreAlbumAssets(id, *resp))
 			return resp.AssetMetadatas, nil
 		} else if err == nil {
 			log.Debug("found stale album asset metadata in local storage",
@@ -187,7 +237,7 @@ func (c Client) GetAlbumAssets(id AlbumID) ([]AssetMetadata, error) {
 		resp, err := c.remote.GetAlbumAssets(id)
 		if err == nil {
 			log.Debug("fetched album asset metadata from remote")
-			log.Debug("storing album asset metadata in cache", "error", c.cache.StoreAlbumAssets(id, *resp))
+			log.Debug("storing album asset metadata in cache", "error", cache.StoreAlbumAssets(id, *resp))
 			log.Debug("storing album asset metadata in local storage", "error", c.local.StoreAlbumAssets(id, *resp))
 			return resp.AssetMetadatas, nil
 		}
@@ -202,6 +252,122 @@ func (c Client) GetAlbumAssets(id AlbumID) ([]AssetMetadata, error) {
 	return nil, errors.New("could not get album asset metadata")
 }
 
+// GetAssetMetadata retrieves the metadata of a single immich asset. It first
+// checks local storage, then the remote server. On success, the local storage
+// (if applicable) is updated.
+func (c Client) GetAssetMetadata(id AssetID) (*AssetMetadata, error) {
+	log := slog.With("id", id)
+	var foundResp *assetMetadataResponse
+	local, ok := c.local.(assetMetadataClient)
+	if ok {
+		resp, err := local.GetAssetMetadata(id)
+		if err == nil && !c.shouldRefresh(resp.ResponseTime) {
+			log.Debug("found asset metadata in local storage",
+				"age", time.Since(resp.ResponseTime).String(),
+				"maxAge", c.refreshInterval.String())
+			return &resp.AssetMetadata, nil
+		} else if err == nil {
+			log.Debug("found stale asset metadata in local storage",
+				"age", time.Since(resp.ResponseTime).String(),
+				"maxAge", c.refreshInterval.String())
+			foundResp = resp
+		} else {
+			log.Debug("failed to get asset metadata from local storage", "error", err)
+		}
+	}
+	md, err := c.remote.GetAssetPreview(id)
+	if err == nil {
+		log.Debug("fetched asset metadata from remote")
+		if ok {
+			resp := assetMetadataResponse{ResponseTime: time.Now(), AssetMetadata: *md}
+			log.Debug("storing asset metadata in local storage", "error", local.StoreAssetMetadata(resp))
+		}
+		return md, nil
+	}
+	log.Debug("failed to get asset metadata from remote", "error", err)
+	if foundResp != nil {
+		log.Debug("failed to get asset metadata, using stale response",
+			"age", time.Since(foundResp.ResponseTime).String(),
+			"maxAge", c.refreshInterval.String())
+		return &foundResp.AssetMetadata, nil
+	}
+	return nil, fmt.Errorf("could not get asset metadata: %w", err)
+}
+
+// GetVideoPath retrieves the video of a video asset, transcoded by immich for
+// playback, and returns the path of its file in local storage, so it can be
+// played without keeping it in memory. It first checks local storage, then
+// downloads it from the remote server. An error is returned if local storage
+// is not configured.
+func (c Client) GetVideoPath(id AssetID) (string, error) {
+	log := slog.With("id", id)
+	local, ok := c.local.(videoClient)
+	if !ok {
+		return "", errors.New("playing videos requires local storage")
+	}
+	path, err := local.VideoPath(id)
+	if err == nil {
+		log.Debug("found video in local storage")
+		return path, nil
+	}
+	log.Debug("failed to get video from local storage", "error", err)
+	data, err := c.remote.GetVideo(id)
+	if err != nil {
+		log.Debug("failed to get video from remote", "error", err)
+		return "", errors.New("could not get video")
+	}
+	log.Info("fetched video from remote", "size", humanize.Bytes(uint64(len(data))))
+	if err := local.StoreVideo(id, data); err != nil {
+		return "", fmt.Errorf("could not store video: %w", err)
+	}
+	return local.VideoPath(id)
+}
+
+// GetLivePhotoVideoPath retrieves the motion video of a Live Photo the same way
+// as [Client.GetVideoPath]. An error is returned if the asset is not a Live
+// Photo.
+func (c Client) GetLivePhotoVideoPath(md AssetMetadata) (string, error) {
+	if md.LivePhotoVideoID == "" {
+		return "", errors.New("asset is not a live photo")
+	}
+	return c.GetVideoPath(md.LivePhotoVideoID)
+}
+
+// GetMemories gets the immich "on this day" memories for the provided day.
+// Memories change daily, so they are always fetched from the remote server.
+func (c Client) GetMemories(day time.Time) ([]Memory, error) {
+	log := slog.With("day", day.Format(time.DateOnly))
+	log.Info("fetching memories from remote")
+	resp, err := c.remote.GetMemories(day)
+	if err != nil {
+		log.Debug("failed to get memories from remote", "error", err)
+		return nil, fmt.Errorf("could not get memories: %w", err)
+	}
+	log.Debug("fetched memories from remote", "memory_count", len(resp.Memories))
+	return resp.Memories, nil
+}
+
+// GetState retrieves the state previously saved with [Client.StoreState]. An
+// error is returned if there is no saved state or local storage is not
+// configured.
+func (c Client) GetState(name string) ([]byte, error) {
+	local, ok := c.local.(stateClient)
+	if !ok {
+		return nil, errors.New("local storage is not configured")
+	}
+	return local.GetState(name)
+}
+
+// StoreState saves the state so it can be retrieved after a restart with
+// [Client.GetState]. State is only saved in local storage.
+func (c Client) StoreState(name string, data []byte) error {
+	local, ok := c.local.(stateClient)
+	if !ok {
+		return errors.New("local storage is not configured")
+	}
+	return local.StoreState(name, data)
+}
+
 func (c Client) shouldRefresh(respTime time.Time) bool {
 	if c.refreshInterval == 0 {
 		return false
@@ -248,10 +414,12 @@ func WithLocalStorage(conf LocalConfig) clientOpt {
 }
 
 // WithRemote adds a remote client. Only one remote client can be configured.
-// If multiple are provided, the last is used.
+// If multiple are provided, the last is used. Requests go through a circuit
+// breaker, so while the server is unavailable they fail immediately and local
+// storage is used instead.
 func WithRemote(conf api.Config) clientOpt {
 	return func(c *Client) {
-		c.remote = api.NewClient(conf)
+		c.remote = breakerClient{remote: api.NewClient(conf), breaker: newCircuitBreaker()}
 	}
 }
 
@@ -274,9 +442,22 @@ func NewClient(opts ...clientOpt) *Client {
 //
 // Used for both in-memory keys and local storage filenames. They don't need to
 // match across implementations, but it's simpler if it does.
-func assetKey(id AssetID) string { return fmt.Sprintf("asset-%s", id) }
-func albumKey(id AlbumID) string { return fmt.Sprintf("album-%s", id) }
-func albumsKey() string          { return "albums" }
+func assetKey(id AssetID, quality AssetQuality) string {
+	return fmt.Sprintf("asset-%s-%s", id, quality)
+}
+func albumKey(id AlbumID) string         { return fmt.Sprintf("album-%s", id) }
+func albumsKey() string                  { return "albums" }
+func stateKey(name string) string        { return fmt.Sprintf("state-%s", name) }
+func assetMetadataKey(id AssetID) string { return fmt.Sprintf("meta-%s", id) }
+func videoKey(id AssetID) string         { return fmt.Sprintf("video-%s", id) }
+
+// isMetadataKey reports whether the key refers to album metadata or saved
+// state rather than an asset.
+func isMetadataKey(key string) bool {
+	return key == albumsKey() ||
+		strings.HasPrefix(key, albumKey("")) ||
+		strings.HasPrefix(key, stateKey(""))
+}
 
 // noopClient provides a noop implementation for the cache, local, and remote
 // clients.
@@ -285,8 +466,15 @@ type noopClient struct{}
 func (noopClient) GetAlbumAssets(AlbumID) (*GetAlbumAssetsResponse, error) {
 	return nil, errors.New("noop")
 }
-func (noopClient) GetAlbums() (*GetAlbumsResponse, error)                 { return nil, errors.New("noop") }
-func (noopClient) GetAsset(AssetMetadata) (*Asset, error)                 { return nil, errors.New("noop") }
+func (noopClient) GetMemories(time.Time) (*GetMemoriesResponse, error) {
+	return nil, errors.New("noop")
+}
+func (noopClient) GetAssetPreview(AssetID) (*AssetMetadata, error) { return nil, errors.New("noop") }
+func (noopClient) GetVideo(AssetID) ([]byte, error)                { return nil, errors.New("noop") }
+func (noopClient) GetAlbums() (*GetAlbumsResponse, error)          { return nil, errors.New("noop") }
+func (noopClient) GetAsset(AssetMetadata, AssetQuality) (*Asset, error) {
+	return nil, errors.New("noop")
+}
 func (noopClient) IsConnected() error                                     { return errors.New("noop") }
 func (noopClient) StoreAlbumAssets(AlbumID, GetAlbumAssetsResponse) error { return errors.New("noop") }
 func (noopClient) StoreAlbums(GetAlbumsResponse) error                    { return errors.New("noop") }
@@ -0,0 +1,142 @@
+package immich
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+// videoRemote is a remoteClient that serves the same video for every asset.
+type videoRemote struct {
+	noopClient
+	requests *int
+	video    []byte
+}
+
+func (v videoRemote) GetVideo(AssetID) ([]byte, error) {
+	*v.requests++
+	return v.video, nil
+}
+
+func TestClientGetVideoPath(t *testing.T) {
+	var requests int
+	remote := videoRemote{requests: &requests, video: []byte("video")}
+	c := Client{cache: noopClient{}, local: noopClient{}, remote: remote}
+	if _, err := c.GetVideoPath("video-1"); err == nil {
+		t.Fatal("expected an error without local storage")
+	}
+
+	c.local = newTestLocalStorageClient(t, t.TempDir(), 1000)
+	for range 2 {
+		path, err := c.GetVideoPath("video-1")
+		if err != nil {
+			t.Fatalf("failed to get video path: %v", err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("failed to read video: %v", err)
+		}
+		if !bytes.Equal(data, remote.video) {
+			t.Fatalf("expected %q, got %q", remote.video, data)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("expected the video to be downloaded once, got %d requests", requests)
+	}
+}
+
+// albumRemote is a remoteClient that serves the same assets for every album.
+type albumRemote struct {
+	noopClient
+	assets []AssetMetadata
+}
+
+func (a albumRemote) GetAlbumAssets(AlbumID) (*GetAlbumAssetsResponse, error) {
+	return &GetAlbumAssetsResponse{ResponseTime: time.Now(), AssetMetadatas: a.assets}, nil
+}
+
+func TestClientGetAlbumAssetsUncached(t *testing.T) {
+	cache := newInMemoryCacheClient(InMemoryConfig{UseInMemoryCache: true, InMemoryCacheSize: 1000})
+	c := Client{cache: cache, local: noopClient{}, remote: albumRemote{assets: []AssetMetadata{{ID: "asset-1"}}}}
+	mds, err := c.GetAlbumAssetsUncached("album-1")
+	if err != nil {
+		t.Fatalf("failed to get album assets: %v", err)
+	}
+	if len(mds) != 1 || mds[0].ID != "asset-1" {
+		t.Fatalf("expected asset-1, got %+v", mds)
+	}
+	if _, err := cache.GetAlbumAssets("album-1"); err == nil {
+		t.Error("expected album assets to not be stored in the cache")
+	}
+
+	if _, err := c.GetAlbumAssets("album-1"); err != nil {
+		t.Fatalf("failed to get album assets: %v", err)
+	}
+	if _, err := cache.GetAlbumAssets("album-1"); err != nil {
+		t.Errorf("expected album assets to be stored in the cache, got error: %v", err)
+	}
+}
+
+// metadataRemote is a remoteClient that serves asset metadata, or err if set.
+type metadataRemote struct {
+	noopClient
+	requests *int
+	err      error
+}
+
+func (m metadataRemote) GetAssetPreview(id AssetID) (*AssetMetadata, error) {
+	*m.requests++
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &AssetMetadata{ID: id, Name: "fresh.jpg"}, nil
+}
+
+func TestClientGetAssetMetadataRefresh(t *testing.T) {
+	var requests int
+	local := newTestLocalStorageClient(t, t.TempDir(), 1000)
+	c := Client{
+		refreshInterval: time.Hour,
+		cache:           noopClient{},
+		local:           local,
+		remote:          metadataRemote{requests: &requests},
+	}
+	for range 2 {
+		if _, err := c.GetAssetMetadata("asset-1"); err != nil {
+			t.Fatalf("failed to get asset metadata: %v", err)
+		}
+	}
+	if requests != 1 {
+		t.Fatalf("expected the metadata to be fetched once, got %d requests", requests)
+	}
+
+	stale := assetMetadataResponse{
+		ResponseTime:  time.Now().Add(-2 * time.Hour),
+		AssetMetadata: AssetMetadata{ID: "asset-1", Name: "stale.jpg"},
+	}
+	if err := local.StoreAssetMetadata(stale); err != nil {
+		t.Fatalf("failed to store asset metadata: %v", err)
+	}
+	md, err := c.GetAssetMetadata("asset-1")
+	if err != nil {
+		t.Fatalf("failed to get asset metadata: %v", err)
+	}
+	if requests != 2 || md.Name != "fresh.jpg" {
+		t.Fatalf("expected stale metadata to be refreshed, got %q after %d requests", md.Name, requests)
+	}
+
+	// Stale metadata is used if the remote server is unavailable.
+	remoteErr := errors.New("unavailable")
+	c.remote = metadataRemote{requests: &requests, err: remoteErr}
+	if err := local.StoreAssetMetadata(stale); err != nil {
+		t.Fatalf("failed to store asset metadata: %v", err)
+	}
+	if md, err := c.GetAssetMetadata("asset-1"); err != nil || md.Name != "stale.jpg" {
+		t.Fatalf("expected stale metadata, got %+v, %v", md, err)
+	}
+	if _, err := c.GetAssetMetadata("asset-2"); !errors.Is(err, remoteErr) {
+		t.Fatalf("expected the remote error to be wrapped, got %v", err)
+	}
+}
@@ -6,11 +6,25 @@ type ClientDiagnostics struct {
 	InMemoryConfigured   bool
 	RemoteConfigured     bool
 	RemoteConnectedError string
+	LocalStorage         *StorageDiagnostics `json:",omitempty"`
+	InMemoryCache        *StorageDiagnostics `json:",omitempty"`
 }
 
-// Diagnostics reports how the client is configured and checks if the remote is connected.
-//
-// TODO: Add in-memory and local information like memory used / configured.
+// StorageDiagnostics reports how much of a client's configured capacity is in
+// use.
+type StorageDiagnostics struct {
+	Entries         int
+	BytesUsed       string
+	BytesConfigured string
+}
+
+// diagnosticsClient is a client that can report its storage usage.
+type diagnosticsClient interface {
+	diagnostics() StorageDiagnostics
+}
+
+// Diagnostics reports how the client is configured, how much of the local
+// storage and in-memory cache is used, and checks if the remote is connected.
 func (c Client) Diagnostics() ClientDiagnostics {
 	remoteConnected := ""
 	if err := c.remote.IsConnected(); err != nil {
@@ -21,5 +35,18 @@ func (c Client) Diagnostics() ClientDiagnostics {
 		InMemoryConfigured:   (c.cache != nil),
 		RemoteConfigured:     (c.remote != nil),
 		RemoteConnectedError: remoteConnected,
+		LocalStorage:         storageDiagnostics(c.local),
+		InMemoryCache:        storageDiagnostics(c.cache),
+	}
+}
+
+// storageDiagnostics is a helper function to get the StorageDiagnostics from
+// the client, if it supports reporting them.
+func storageDiagnostics(client any) *StorageDiagnostics {
+	dc, ok := client.(diagnosticsClient)
+	if !ok {
+		return nil
 	}
+	d := dc.diagnostics()
+	return &d
 }
@@ -0,0 +1,285 @@
+package immich
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// indexFileName is the name of the file in the local storage directory that
+// persists the [localIndex] across restarts. It is prefixed with a dot so it
+// can never collide with a key.
+const indexFileName = ".index.json"
+
+// indexEntry holds the bookkeeping information for a single key in local
+// storage.
+type indexEntry struct {
+	Size       int64
+	LastAccess time.Time
+	// Checksum is the hex-encoded CRC-32C of the data, or empty if it is
+	// not known yet (e.g. after rebuilding the index).
+	Checksum string
+}
+
+// localIndex tracks the size, last access time, and checksum of each key in
+// local storage so space can be calculated and the least recently used assets
+// evicted without walking the directory. It is persisted to disk and is safe
+// for concurrent use.
+type localIndex struct {
+	mu         sync.Mutex
+	dir        string
+	entries    map[string]indexEntry
+	bytesInUse int64
+	// reserved is the number of bytes claimed by stores that are still
+	// writing their files, and stored is signaled when one finishes.
+	reserved int64
+	stored   *sync.Cond
+	lastSave time.Time
+	// accessSaveInterval limits how often access-time-only updates are
+	// written to disk, since every read would otherwise rewrite the index.
+	// Changes to the stored keys are always written immediately.
+	accessSaveInterval time.Duration
+}
+
+// loadLocalIndex reads the index from the provided local storage directory. If
+// the index does not exist or cannot be decoded, it is rebuilt from the
+// contents of the directory.
+func loadLocalIndex(dir string) *localIndex {
+	x := &localIndex{
+		dir:                dir,
+		entries:            make(map[string]indexEntry),
+		accessSaveInterval: time.Minute,
+	}
+	x.stored = sync.NewCond(&x.mu)
+	err := x.load()
+	if err == nil {
+		return x
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		slog.Debug("local storage index not found, rebuilding", "path", x.path())
+	} else {
+		slog.Warn("local storage index is corrupt, rebuilding", "path", x.path(), "error", err)
+	}
+	if err := x.rebuild(); err != nil {
+		slog.Error("failed to rebuild local storage index", "error", err)
+	}
+	return x
+}
+
+// path returns the filepath of the persisted index.
+func (x *localIndex) path() string {
+	return filepath.Join(x.dir, indexFileName)
+}
+
+// load reads and decodes the persisted index.
+func (x *localIndex) load() error {
+	data, err := os.ReadFile(x.path())
+	if err != nil {
+		return err
+	}
+	var entries map[string]indexEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return err
+	}
+	var bytesInUse int64
+	for key, entry := range entries {
+		if entry.Size < 0 || !isStorageKey(key) {
+			return fmt.Errorf("invalid entry for key %q", key)
+		}
+		bytesInUse += entry.Size
+	}
+	x.entries = entries
+	x.bytesInUse = bytesInUse
+	return nil
+}
+
+// rebuild replaces the index with the files found in the local storage
+// directory and persists it. Since the files were not read, their checksums
+// are left empty and last access times fall back to the modification time.
+func (x *localIndex) rebuild() error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	dirEntries, err := os.ReadDir(x.dir)
+	if err != nil {
+		return err
+	}
+	x.entries = make(map[string]indexEntry)
+	x.bytesInUse = 0
+	for _, d := range dirEntries {
+		if d.IsDir() || !isStorageKey(d.Name()) {
+			continue
+		}
+		info, err := d.Info()
+		if err != nil {
+			continue
+		}
+		x.entries[d.Name()] = indexEntry{
+			Size:       info.Size(),
+			LastAccess: info.ModTime(),
+		}
+		x.bytesInUse += info.Size()
+	}
+	slog.Info("rebuilt local storage index",
+		"entries", len(x.entries),
+		"bytes", x.bytesInUse,
+	)
+	return x.save()
+}
+
+// get returns the index entry for the key, if it exists.
+func (x *localIndex) get(key string) (indexEntry, bool) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	entry, ok := x.entries[key]
+	return entry, ok
+}
+
+// put records that data was written for the key and persists the index. The
+// caller must hold the lock.
+func (x *localIndex) put(key string, data []byte) error {
+	x.bytesInUse -= x.entries[key].Size
+	x.entries[key] = indexEntry{
+		Size:       int64(len(data)),
+		LastAccess: time.Now(),
+		Checksum:   checksum(data),
+	}
+	x.bytesInUse += int64(len(data))
+	return x.save()
+}
+
+// touch marks the key as accessed now. If the entry does not have a checksum
+// yet, it is set from the data that was read. Access time updates are only
+// persisted every accessSaveInterval.
+func (x *localIndex) touch(key string, data []byte) error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	entry, ok := x.entries[key]
+	if !ok {
+		entry.Size = int64(len(data))
+		x.bytesInUse += entry.Size
+	}
+	if entry.Checksum == "" {
+		entry.Checksum = checksum(data)
+	}
+	entry.LastAccess = time.Now()
+	x.entries[key] = entry
+	if ok && time.Since(x.lastSave) < x.accessSaveInterval {
+		return nil
+	}
+	return x.save()
+}
+
+// touchUnread is like [localIndex.touch] for a key whose data was not read,
+// such as a video that is played from its file. Its checksum is left as is.
+func (x *localIndex) touchUnread(key string, size int64) error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	entry, ok := x.entries[key]
+	if !ok {
+		entry.Size = size
+		x.bytesInUse += entry.Size
+	}
+	entry.LastAccess = time.Now()
+	x.entries[key] = entry
+	if ok && time.Since(x.lastSave) < x.accessSaveInterval {
+		return nil
+	}
+	return x.save()
+}
+
+// remove deletes the keys from the index and persists the index.
+func (x *localIndex) remove(keys ...string) error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return x.removeLocked(keys...)
+}
+
+// removeLocked is like [localIndex.remove], but the caller must hold the lock.
+func (x *localIndex) removeLocked(keys ...string) error {
+	for _, key := range keys {
+		x.bytesInUse -= x.entries[key].Size
+		delete(x.entries, key)
+	}
+	return x.save()
+}
+
+// usage returns the total number of bytes and number of keys tracked by the
+// index.
+func (x *localIndex) usage() (int64, int) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return x.bytesInUse, len(x.entries)
+}
+
+// evictionCandidate is a key in local storage that may be evicted.
+type evictionCandidate struct {
+	key        string
+	size       int64
+	lastAccess time.Time
+}
+
+// evictionCandidates lists the keys in the index that can be evicted, least
+// recently used first. The excluded key is never returned. The caller must
+// hold the lock.
+func (x *localIndex) evictionCandidates(exclude string) []evictionCandidate {
+	var candidates []evictionCandidate
+	for key, entry := range x.entries {
+		if key == exclude || !isEvictableKey(key) {
+			continue
+		}
+		candidates = append(candidates, evictionCandidate{key, entry.Size, entry.LastAccess})
+	}
+	slices.SortFunc(candidates, func(a, b evictionCandidate) int {
+		return a.lastAccess.Compare(b.lastAccess)
+	})
+	return candidates
+}
+
+// save writes the index to disk. It writes to a temporary file first so a
+// crash cannot leave a partially written index behind. The caller must hold
+// the lock.
+func (x *localIndex) save() error {
+	data, err := json.Marshal(x.entries)
+	if err != nil {
+		return err
+	}
+	tmpPath := x.path() + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, x.path()); err != nil {
+		return err
+	}
+	x.lastSave = time.Now()
+	return nil
+}
+
+// castagnoliTable is the CRC-32C table used by [checksum].
+var castagnoliTable = crc32.MakeTable(crc32.Castagnoli)
+
+// checksum is a helper function to calculate the checksum stored in the
+// index.
+func checksum(data []byte) string {
+	return fmt.Sprintf("%08x", crc32.Checksum(data, castagnoliTable))
+}
+
+// isStorageKey reports whether the filename is a key, as opposed to a
+// bookkeeping dotfile such as the index.
+func isStorageKey(name string) bool {
+	return !strings.HasPrefix(name, ".")
+}
+
+// isEvictableKey reports whether the key is allowed to be evicted. Album
+// metadata is pinned so the frame can always start without the immich
+// server, and saved state is pinned so it survives restarts.
+func isEvictableKey(key string) bool {
+	return isStorageKey(key) && !isMetadataKey(key)
+}
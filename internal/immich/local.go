@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io/fs"
 	"log/slog"
 	"os"
 	"path/filepath"
@@ -15,7 +14,8 @@ import (
 // localStorageClient is a rwClient for storing and retrieving assets and
 // metadata from local persistent storage.
 type localStorageClient struct {
-	conf LocalConfig
+	conf  LocalConfig
+	index *localIndex
 }
 
 // GetAlbumAssets attempts to retrieve the asset metadata for the given album
@@ -69,106 +69,247 @@ func (l localStorageClient) StoreAlbums(resp GetAlbumsResponse) error {
 	return l.store(key, data)
 }
 
+// GetAssetMetadata attempts to retrieve the metadata of a single asset from
+// the filesystem. An error is returned if the data is not available.
+func (l localStorageClient) GetAssetMetadata(id AssetID) (*assetMetadataResponse, error) {
+	data, err := l.get(assetMetadataKey(id))
+	if err != nil {
+		return nil, err
+	}
+	var resp assetMetadataResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// StoreAssetMetadata attempts to write the metadata of a single asset to the
+// filesystem.
+func (l localStorageClient) StoreAssetMetadata(resp assetMetadataResponse) error {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	return l.store(assetMetadataKey(resp.ID), data)
+}
+
+// VideoPath attempts to find the file of the video of a video asset on the
+// filesystem, without reading it. An error is returned if it is not available.
+func (l localStorageClient) VideoPath(id AssetID) (string, error) {
+	key := videoKey(id)
+	path := filepath.Join(l.conf.LocalStoragePath, key)
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		// Keep the index in sync if the file was removed externally.
+		if _, ok := l.index.get(key); ok {
+			slog.Debug("removing missing key from local storage index", "key", key,
+				"error", l.index.remove(key))
+		}
+		return "", err
+	} else if err != nil {
+		return "", err
+	}
+	if err := l.index.touchUnread(key, info.Size()); err != nil {
+		slog.Debug("failed to update local storage index", "key", key, "error", err)
+	}
+	return path, nil
+}
+
+// StoreVideo attempts to write the video of a video asset to the filesystem.
+func (l localStorageClient) StoreVideo(id AssetID, data []byte) error {
+	return l.store(videoKey(id), data)
+}
+
+// GetState attempts to retrieve the named state from the filesystem. An error
+// is returned if the data is not available.
+func (l localStorageClient) GetState(name string) ([]byte, error) {
+	return l.get(stateKey(name))
+}
+
+// StoreState attempts to write the named state to the filesystem.
+func (l localStorageClient) StoreState(name string, data []byte) error {
+	return l.store(stateKey(name), data)
+}
+
 // GetAsset attempts to retrieve the asset from the filesystem. An error is
 // returned if the data is not available.
-func (l localStorageClient) GetAsset(md AssetMetadata) (*Asset, error) {
-	key := assetKey(md.ID)
+func (l localStorageClient) GetAsset(md AssetMetadata, quality AssetQuality) (*Asset, error) {
+	key := assetKey(md.ID, quality)
 	data, err := l.get(key)
 	if err != nil {
 		return nil, err
 	}
 	return &Asset{
-		Meta: md,
-		Data: data,
+		Meta:    md,
+		Data:    data,
+		Quality: quality,
 	}, nil
 }
 
 // StoreAsset attempts to write the asset to the filesystem.
 func (l localStorageClient) StoreAsset(asset *Asset) error {
-	key := assetKey(asset.Meta.ID)
+	key := assetKey(asset.Meta.ID, asset.Quality)
 	return l.store(key, asset.Data)
 }
 
 // get is a helper method to convert the key to a filepath and read the
-// contents of the file.
+// contents of the file. On success, the key is marked as recently used. If the
+// contents do not match the checksum in the index, the key is removed and an
+// error is returned.
 func (l localStorageClient) get(key string) ([]byte, error) {
-	return os.ReadFile(filepath.Join(l.conf.LocalStoragePath, key))
+	path := filepath.Join(l.conf.LocalStoragePath, key)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		// Keep the index in sync if the file was removed externally.
+		if _, ok := l.index.get(key); ok {
+			slog.Debug("removing missing key from local storage index", "key", key,
+				"error", l.index.remove(key))
+		}
+		return nil, err
+	} else if err != nil {
+		return nil, err
+	}
+	if entry, ok := l.index.get(key); ok && entry.Checksum != "" && entry.Checksum != checksum(data) {
+		slog.Warn("removing corrupt key from local storage", "key", key,
+			"error", errors.Join(os.Remove(path), l.index.remove(key)))
+		return nil, fmt.Errorf("checksum mismatch for key %q", key)
+	}
+	if err := l.index.touch(key, data); err != nil {
+		slog.Debug("failed to update local storage index", "key", key, "error", err)
+	}
+	return data, nil
 }
 
 // store is a helper method to convert the key to a filepath and write the data
-// to disk.
+// to disk. Space is reserved under the index lock, so concurrent stores can't
+// claim the same free space and overshoot the configured size, but the file is
+// written without it so reads and other stores don't wait on large writes. If
+// the space is held by stores still in flight, it waits for them to finish
+// before evicting.
 func (l localStorageClient) store(key string, data []byte) error {
-	path := filepath.Join(l.conf.LocalStoragePath, key)
-	if hasSpace, err := l.hasSpace(path, int64(len(data))); err == nil && !hasSpace {
-		if err := l.evict(len(data)); err != nil {
+	size := int64(len(data))
+	l.index.mu.Lock()
+	for !l.hasSpace(key, size) {
+		err := l.evict(key, size)
+		if err == nil {
+			break
+		}
+		if l.index.reserved == 0 {
+			l.index.mu.Unlock()
 			return fmt.Errorf("not enough space: %w", err)
 		}
-	} else if err != nil {
-		return fmt.Errorf("failed to check local storage space: %w", err)
+		l.index.stored.Wait()
 	}
-	return os.WriteFile(filepath.Join(l.conf.LocalStoragePath, key), data, 0644)
-}
+	l.index.reserved += size
+	l.index.mu.Unlock()
 
-// hasSpace calculates the amount of space used in the configured path and
-// checks if there is enough space to hold the amount of bytes requested. The
-// path argument is the path in which the amount of bytes will go, so if there
-// is an existing file, we can "reclaim" some of that space.
-func (l localStorageClient) hasSpace(path string, bytesRequested int64) (bool, error) {
-	bytesUsed, err := l.bytesInUse()
+	err := l.writeFile(key, data)
+
+	l.index.mu.Lock()
+	defer l.index.mu.Unlock()
+	l.index.reserved -= size
+	l.index.stored.Broadcast()
 	if err != nil {
-		return false, fmt.Errorf("failed to calculate bytes used: %w", err)
+		return err
 	}
+	if err := l.index.put(key, data); err != nil {
+		slog.Debug("failed to update local storage index", "key", key, "error", err)
+	}
+	return nil
+}
 
-	bytesToReplace := int64(0)
-	{
-		info, err := os.Stat(path)
-		if err != nil && !errors.Is(err, os.ErrNotExist) {
-			slog.Debug("could not stat file", "path", path, "error", err)
-			return false, fmt.Errorf("failed to get existing bytes for file: %w", err)
-		} else if err == nil {
-			bytesToReplace = info.Size()
-		}
+// writeFile is a helper method to write the data for the key to a temporary
+// file, which is hidden from the index, and rename it into place.
+func (l localStorageClient) writeFile(key string, data []byte) error {
+	f, err := os.CreateTemp(l.conf.LocalStoragePath, ".store-*")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), filepath.Join(l.conf.LocalStoragePath, key))
 	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+// hasSpace uses the index to check if there is enough space to hold the amount
+// of bytes requested for the key. If the key already exists, we can "reclaim"
+// that space since it will be replaced. The caller must hold the index lock.
+func (l localStorageClient) hasSpace(key string, bytesRequested int64) bool {
+	bytesUsed := l.index.bytesInUse + l.index.reserved
+	bytesToReplace := l.index.entries[key].Size
 
 	slog.Debug("calculated local storage space",
 		"storage_configured", l.conf.LocalStorageSize.String(),
 		"storage_used", humanize.Bytes(uint64(bytesUsed)),
-		"storage_needed", humanize.Bytes(uint64(bytesRequested-bytesToReplace)),
+		"storage_needed", humanize.Bytes(uint64(max(bytesRequested-bytesToReplace, 0))),
 	)
-	return bytesUsed-bytesToReplace+bytesRequested <= int64(l.conf.LocalStorageSize), nil
-}
-
-// bytesInUse calculates the number of bytes used in the configured path. It
-// takes a best-effort approach and only returns an error if there was an error
-// opening the top-level directory.
-func (l localStorageClient) bytesInUse() (int64, error) {
-	var totalSize int64
-	err := filepath.WalkDir(l.conf.LocalStoragePath, func(path string, d fs.DirEntry, err error) error {
-		// If there was an error opening the root path, surface that error.
-		if path == l.conf.LocalStoragePath && err != nil {
-			return err
+	return bytesUsed-bytesToReplace+bytesRequested <= int64(l.conf.LocalStorageSize)
+}
+
+// evict attempts to make enough room in the configured directory path to hold
+// nbytes more bytes for the provided key. Assets are removed in least recently
+// used order. Album metadata, saved state, and the key being written are never
+// evicted. The caller must hold the index lock.
+func (l localStorageClient) evict(key string, nbytes int64) error {
+	if nbytes > int64(l.conf.LocalStorageSize) {
+		return errors.New("data is larger than the configured local storage size")
+	}
+	bytesToFree := l.index.bytesInUse + l.index.reserved - l.index.entries[key].Size + nbytes - int64(l.conf.LocalStorageSize)
+
+	var evicted []string
+	defer func() {
+		if len(evicted) == 0 {
+			return
+		}
+		if err := l.index.removeLocked(evicted...); err != nil {
+			slog.Debug("failed to update local storage index", "error", err)
 		}
-		// Otherwise, ignore errors and directories.
-		if err != nil || d.IsDir() {
-			return nil
+	}()
+	for _, c := range l.index.evictionCandidates(key) {
+		if bytesToFree <= 0 {
+			break
 		}
-		// Ignore any errors to do a best effort approach.
-		if info, err := d.Info(); err == nil {
-			totalSize += info.Size()
+		err := os.Remove(filepath.Join(l.conf.LocalStoragePath, c.key))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			slog.Debug("failed to evict key", "key", c.key, "error", err)
+			continue
 		}
-		return nil
-	})
-	return totalSize, err
+		slog.Debug("evicted key from local storage",
+			"key", c.key,
+			"size", humanize.Bytes(uint64(c.size)),
+			"last_access", c.lastAccess,
+		)
+		evicted = append(evicted, c.key)
+		bytesToFree -= c.size
+	}
+	if bytesToFree > 0 {
+		return errors.New("not enough evictable data")
+	}
+	return nil
 }
 
-// evict attempts to make enough room in the configured directory path to hold
-// nbytes more bytes.
-func (l localStorageClient) evict(nbytes int) error {
-	return errors.New("no eviction policy")
+// diagnostics reports how much of the configured local storage is in use.
+func (l localStorageClient) diagnostics() StorageDiagnostics {
+	bytesUsed, entries := l.index.usage()
+	return StorageDiagnostics{
+		Entries:         entries,
+		BytesUsed:       humanize.Bytes(uint64(bytesUsed)),
+		BytesConfigured: l.conf.LocalStorageSize.String(),
+	}
 }
 
 // newInMemoryCacheClient initializes a [localStorageClient] client.
 func newLocalStorageClient(conf LocalConfig) localStorageClient {
 	conf.LocalStoragePath = filepath.Clean(conf.LocalStoragePath)
-	return localStorageClient{conf}
+	return localStorageClient{
+		conf:  conf,
+		index: loadLocalIndex(conf.LocalStoragePath),
+	}
 }
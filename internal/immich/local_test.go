@@ -0,0 +1,258 @@
+package immich
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// newTestLocalStorageClient is a helper function to create a
+// localStorageClient backed by a temporary directory.
+func newTestLocalStorageClient(t *testing.T, dir string, size HumanBytes) localStorageClient {
+	t.Helper()
+	l := newLocalStorageClient(LocalConfig{
+		UseLocalStorage:  true,
+		LocalStorageSize: size,
+		LocalStoragePath: dir,
+	})
+	// Persist every access so restarts can be simulated.
+	l.index.accessSaveInterval = 0
+	return l
+}
+
+// testAsset is a helper function to create an asset with n bytes of data.
+func testAsset(id AssetID, n int) *Asset {
+	return &Asset{
+		Meta:    AssetMetadata{ID: id},
+		Data:    bytes.Repeat([]byte{'x'}, n),
+		Quality: AssetQualityPreview,
+	}
+}
+
+// assertStored is a helper function to check which asset IDs are (or are not)
+// in local storage.
+func assertStored(t *testing.T, l localStorageClient, want map[AssetID]bool) {
+	t.Helper()
+	for id, stored := range want {
+		_, err := os.Stat(filepath.Join(l.conf.LocalStoragePath, assetKey(id, AssetQualityPreview)))
+		if stored && err != nil {
+			t.Errorf("expected %q to be stored, got error: %v", id, err)
+		} else if !stored && err == nil {
+			t.Errorf("expected %q to be evicted", id)
+		}
+	}
+}
+
+func TestLocalStorageEvictsLeastRecentlyUsed(t *testing.T) {
+	l := newTestLocalStorageClient(t, t.TempDir(), 1000)
+	for _, id := range []AssetID{"asset-1", "asset-2", "asset-3"} {
+		if err := l.StoreAsset(testAsset(id, 300)); err != nil {
+			t.Fatalf("failed to store %q: %v", id, err)
+		}
+	}
+	// Access asset-1 so asset-2 becomes the least recently used.
+	if _, err := l.GetAsset(AssetMetadata{ID: "asset-1"}, AssetQualityPreview); err != nil {
+		t.Fatalf("failed to get asset-1: %v", err)
+	}
+	if err := l.StoreAsset(testAsset("asset-4", 300)); err != nil {
+		t.Fatalf("failed to store asset-4: %v", err)
+	}
+	assertStored(t, l, map[AssetID]bool{
+		"asset-1": true,
+		"asset-2": false,
+		"asset-3": true,
+		"asset-4": true,
+	})
+}
+
+func TestLocalStorageEvictsMultiple(t *testing.T) {
+	l := newTestLocalStorageClient(t, t.TempDir(), 1000)
+	for _, id := range []AssetID{"asset-1", "asset-2", "asset-3"} {
+		if err := l.StoreAsset(testAsset(id, 300)); err != nil {
+			t.Fatalf("failed to store %q: %v", id, err)
+		}
+	}
+	if err := l.StoreAsset(testAsset("asset-4", 600)); err != nil {
+		t.Fatalf("failed to store asset-4: %v", err)
+	}
+	assertStored(t, l, map[AssetID]bool{
+		"asset-1": false,
+		"asset-2": false,
+		"asset-3": true,
+		"asset-4": true,
+	})
+}
+
+func TestLocalStoragePinsMetadata(t *testing.T) {
+	l := newTestLocalStorageClient(t, t.TempDir(), 1000)
+	if err := l.StoreAlbums(GetAlbumsResponse{Albums: []Album{{ID: "album-1"}}}); err != nil {
+		t.Fatalf("failed to store albums: %v", err)
+	}
+	if err := l.StoreAlbumAssets("album-1", GetAlbumAssetsResponse{
+		AssetMetadatas: []AssetMetadata{{ID: "asset-1"}},
+	}); err != nil {
+		t.Fatalf("failed to store album assets: %v", err)
+	}
+	if err := l.StoreState("plan", []byte(`{"index":1}`)); err != nil {
+		t.Fatalf("failed to store state: %v", err)
+	}
+	if err := l.StoreAsset(testAsset("asset-1", 500)); err != nil {
+		t.Fatalf("failed to store asset-1: %v", err)
+	}
+	if err := l.StoreAsset(testAsset("asset-2", 500)); err != nil {
+		t.Fatalf("failed to store asset-2: %v", err)
+	}
+
+	if _, err := l.GetAlbums(); err != nil {
+		t.Errorf("expected albums to be pinned, got error: %v", err)
+	}
+	if _, err := l.GetAlbumAssets("album-1"); err != nil {
+		t.Errorf("expected album assets to be pinned, got error: %v", err)
+	}
+	if _, err := l.GetState("plan"); err != nil {
+		t.Errorf("expected state to be pinned, got error: %v", err)
+	}
+	assertStored(t, l, map[AssetID]bool{
+		"asset-1": false,
+		"asset-2": true,
+	})
+}
+
+func TestLocalStorageTooLarge(t *testing.T) {
+	l := newTestLocalStorageClient(t, t.TempDir(), 100)
+	if err := l.StoreAsset(testAsset("asset-1", 101)); err == nil {
+		t.Fatal("expected an error storing an asset larger than local storage")
+	}
+}
+
+func TestLocalStorageConcurrentStores(t *testing.T) {
+	l := newTestLocalStorageClient(t, t.TempDir(), 1000)
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Go(func() {
+			if err := l.StoreAsset(testAsset(AssetID(fmt.Sprintf("asset-%d", i)), 300)); err != nil {
+				t.Errorf("failed to store asset-%d: %v", i, err)
+			}
+		})
+	}
+	wg.Wait()
+	if bytesUsed, entries := l.index.usage(); bytesUsed > 1000 || entries != 3 {
+		t.Errorf("expected 3 entries within 1000 bytes, got %d entries using %d bytes", entries, bytesUsed)
+	}
+	dirEntries, err := os.ReadDir(l.conf.LocalStoragePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var files int
+	for _, d := range dirEntries {
+		if isStorageKey(d.Name()) {
+			files++
+		}
+	}
+	if files != 3 {
+		t.Errorf("expected 3 files in local storage, got %d", files)
+	}
+}
+
+func TestLocalStorageIndexPersists(t *testing.T) {
+	dir := t.TempDir()
+	{
+		l := newTestLocalStorageClient(t, dir, 1000)
+		for _, id := range []AssetID{"asset-1", "asset-2", "asset-3"} {
+			if err := l.StoreAsset(testAsset(id, 300)); err != nil {
+				t.Fatalf("failed to store %q: %v", id, err)
+			}
+		}
+		if _, err := l.GetAsset(AssetMetadata{ID: "asset-1"}, AssetQualityPreview); err != nil {
+			t.Fatalf("failed to get asset-1: %v", err)
+		}
+	}
+
+	// Simulate a restart by creating a new client for the same directory.
+	l := newTestLocalStorageClient(t, dir, 1000)
+	if err := l.StoreAsset(testAsset("asset-4", 300)); err != nil {
+		t.Fatalf("failed to store asset-4: %v", err)
+	}
+	assertStored(t, l, map[AssetID]bool{
+		"asset-1": true,
+		"asset-2": false,
+		"asset-3": true,
+		"asset-4": true,
+	})
+}
+
+func TestLocalStorageIndexRebuild(t *testing.T) {
+	dir := t.TempDir()
+	{
+		l := newTestLocalStorageClient(t, dir, 1000)
+		for _, id := range []AssetID{"asset-1", "asset-2"} {
+			if err := l.StoreAsset(testAsset(id, 300)); err != nil {
+				t.Fatalf("failed to store %q: %v", id, err)
+			}
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, indexFileName), []byte("{corrupt"), 0644); err != nil {
+		t.Fatalf("failed to corrupt index: %v", err)
+	}
+
+	l := newTestLocalStorageClient(t, dir, 1000)
+	if diag := l.diagnostics(); diag.Entries != 2 {
+		t.Fatalf("expected 2 entries after rebuild, got %d", diag.Entries)
+	}
+	if bytesUsed, _ := l.index.usage(); bytesUsed != 600 {
+		t.Fatalf("expected 600 bytes in use after rebuild, got %d", bytesUsed)
+	}
+	// Reading a key fills in its checksum.
+	if _, err := l.GetAsset(AssetMetadata{ID: "asset-1"}, AssetQualityPreview); err != nil {
+		t.Fatalf("failed to get asset-1: %v", err)
+	}
+	if entry, _ := l.index.get(assetKey("asset-1", AssetQualityPreview)); entry.Checksum == "" {
+		t.Fatal("expected checksum to be set after reading")
+	}
+}
+
+func TestLocalStorageChecksumMismatch(t *testing.T) {
+	l := newTestLocalStorageClient(t, t.TempDir(), 1000)
+	if err := l.StoreAsset(testAsset("asset-1", 300)); err != nil {
+		t.Fatalf("failed to store asset-1: %v", err)
+	}
+	path := filepath.Join(l.conf.LocalStoragePath, assetKey("asset-1", AssetQualityPreview))
+	if err := os.WriteFile(path, []byte("corrupt"), 0644); err != nil {
+		t.Fatalf("failed to corrupt asset-1: %v", err)
+	}
+
+	if _, err := l.GetAsset(AssetMetadata{ID: "asset-1"}, AssetQualityPreview); err == nil {
+		t.Fatal("expected an error reading a corrupt asset")
+	}
+	assertStored(t, l, map[AssetID]bool{"asset-1": false})
+	if bytesUsed, entries := l.index.usage(); bytesUsed != 0 || entries != 0 {
+		t.Fatalf("expected empty index, got %d bytes and %d entries", bytesUsed, entries)
+	}
+}
+
+func TestLocalStorageKeepsQualitiesSeparate(t *testing.T) {
+	l := newTestLocalStorageClient(t, t.TempDir(), 1000)
+	preview := testAsset("asset-1", 100)
+	original := testAsset("asset-1", 300)
+	original.Quality = AssetQualityOriginal
+	for _, ass := range []*Asset{preview, original} {
+		if err := l.StoreAsset(ass); err != nil {
+			t.Fatalf("failed to store %s asset: %v", ass.Quality, err)
+		}
+	}
+	for _, want := range []*Asset{preview, original} {
+		got, err := l.GetAsset(AssetMetadata{ID: "asset-1"}, want.Quality)
+		if err != nil {
+			t.Fatalf("failed to get %s asset: %v", want.Quality, err)
+		}
+		if len(got.Data) != len(want.Data) || got.Quality != want.Quality {
+			t.Errorf("expected %d bytes of %s asset, got %d bytes of %s", len(want.Data), want.Quality, len(got.Data), got.Quality)
+		}
+	}
+	if _, err := l.GetAsset(AssetMetadata{ID: "asset-1"}, AssetQualityFullsize); err == nil {
+		t.Error("expected no fullsize asset")
+	}
+}
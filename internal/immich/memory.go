@@ -3,6 +3,9 @@ package immich
 import (
 	"errors"
 	"fmt"
+	"log/slog"
+	"math"
+	"sync"
 
 	"github.com/dustin/go-humanize"
 	lru "github.com/hashicorp/golang-lru/v2"
@@ -11,16 +14,23 @@ import (
 // inMemoryCache is a rwClient for storing and retrieving assets and metadata
 // in-memory.
 type inMemoryCache struct {
-	// TODO: Maybe don't use the LRU for metadata storage.
-	*lru.Cache[string, any]
 	conf InMemoryConfig
+	// mu guards bytesInUse and metadata, and serializes writes to assets
+	// so bytesInUse stays in sync with its contents.
+	mu sync.Mutex
+	// assets is evicted in least recently used order once the total size
+	// of the asset data exceeds the configured size.
+	assets     *lru.Cache[string, *Asset]
+	bytesInUse uint64
+	// metadata holds album metadata, which is small and never evicted.
+	metadata map[string]any
 }
 
 // GetAlbumAssets attempts to retrieve the asset metadata for the given album
 // from the cache. An error is returned if the data is not available.
-func (i inMemoryCache) GetAlbumAssets(id AlbumID) (*GetAlbumAssetsResponse, error) {
+func (i *inMemoryCache) GetAlbumAssets(id AlbumID) (*GetAlbumAssetsResponse, error) {
 	key := albumKey(id)
-	val, err := i.get(key)
+	val, err := i.getMetadata(key)
 	if err != nil {
 		return nil, err
 	}
@@ -37,22 +47,22 @@ func (i inMemoryCache) GetAlbumAssets(id AlbumID) (*GetAlbumAssetsResponse, erro
 }
 
 // StoreAlbumAssets writes the asset metadata for the given album to the cache.
-func (i inMemoryCache) StoreAlbumAssets(id AlbumID, resp GetAlbumAssetsResponse) error {
+func (i *inMemoryCache) StoreAlbumAssets(id AlbumID, resp GetAlbumAssetsResponse) error {
 	// Make a copy so the cache cannot be modified.
 	assetCopy := make([]AssetMetadata, len(resp.AssetMetadatas))
 	copy(assetCopy, resp.AssetMetadatas)
 	resp.AssetMetadatas = assetCopy
 
 	key := albumKey(id)
-	i.Add(key, resp)
+	i.storeMetadata(key, resp)
 	return nil
 }
 
 // GetAlbums attempts to retrieve the list of albums from the cache. An error
 // is returned if the data is not available.
-func (i inMemoryCache) GetAlbums() (*GetAlbumsResponse, error) {
+func (i *inMemoryCache) GetAlbums() (*GetAlbumsResponse, error) {
 	key := albumsKey()
-	val, err := i.get(key)
+	val, err := i.getMetadata(key)
 	if err != nil {
 		return nil, err
 	}
@@ -69,59 +79,98 @@ func (i inMemoryCache) GetAlbums() (*GetAlbumsResponse, error) {
 }
 
 // StoreAlbums writes the list of albums to the cache.
-func (i inMemoryCache) StoreAlbums(resp GetAlbumsResponse) error {
+func (i *inMemoryCache) StoreAlbums(resp GetAlbumsResponse) error {
 	// Make a copy so the cache cannot be modified.
 	albumCopy := make([]Album, len(resp.Albums))
 	copy(albumCopy, resp.Albums)
 	resp.Albums = albumCopy
 
 	key := albumsKey()
-	i.Add(key, resp)
+	i.storeMetadata(key, resp)
 	return nil
 }
 
 // GetAsset attempts to retrieve the asset from the cache. An error is returned
 // if the data is not available.
-func (i inMemoryCache) GetAsset(md AssetMetadata) (*Asset, error) {
-	key := assetKey(md.ID)
-	val, err := i.get(key)
-	if err != nil {
-		return nil, err
-	}
-	ass, ok := val.(*Asset)
+func (i *inMemoryCache) GetAsset(md AssetMetadata, quality AssetQuality) (*Asset, error) {
+	key := assetKey(md.ID, quality)
+	ass, ok := i.assets.Get(key)
 	if !ok {
-		return nil, fmt.Errorf("unexpected asset type: %T", val)
+		return nil, errors.New("not found")
 	}
 	// Let's assume callers will be responsible and not modify ass.Data
 	return ass, nil
 }
 
-// StoreAsset writes the asset to the cache.
-func (i inMemoryCache) StoreAsset(ass *Asset) error {
+// StoreAsset writes the asset to the cache, evicting the least recently used
+// assets until the cache fits within the configured size.
+func (i *inMemoryCache) StoreAsset(ass *Asset) error {
 	// Let's assume callers will be responsible and not modify ass.Data
-	// TODO: Evict when cache size > configured size.
-	key := assetKey(ass.Meta.ID)
-	i.Add(key, ass)
+	size := uint64(len(ass.Data))
+	if size > uint64(i.conf.InMemoryCacheSize) {
+		return errors.New("asset is larger than the configured in-memory cache size")
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	key := assetKey(ass.Meta.ID, ass.Quality)
+	if old, ok := i.assets.Peek(key); ok {
+		i.bytesInUse -= uint64(len(old.Data))
+	}
+	i.assets.Add(key, ass)
+	i.bytesInUse += size
+	for i.bytesInUse > uint64(i.conf.InMemoryCacheSize) {
+		evictedKey, evicted, ok := i.assets.RemoveOldest()
+		if !ok {
+			break
+		}
+		i.bytesInUse -= uint64(len(evicted.Data))
+		slog.Debug("evicted asset from cache",
+			"key", evictedKey,
+			"size", humanize.Bytes(uint64(len(evicted.Data))),
+		)
+	}
 	return nil
 }
 
-// get is a helper method to return an error if the key does not exist in the
-// cache.
-func (i inMemoryCache) get(key string) (any, error) {
-	v, ok := i.Get(key)
+// getMetadata is a helper method to return an error if the key does not exist
+// in the metadata store.
+func (i *inMemoryCache) getMetadata(key string) (any, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	v, ok := i.metadata[key]
 	if !ok {
 		return nil, errors.New("not found")
 	}
 	return v, nil
 }
 
+// storeMetadata is a helper method to write the value to the metadata store.
+func (i *inMemoryCache) storeMetadata(key string, v any) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.metadata[key] = v
+}
+
+// diagnostics reports how much of the configured in-memory cache is in use.
+func (i *inMemoryCache) diagnostics() StorageDiagnostics {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return StorageDiagnostics{
+		Entries:         i.assets.Len() + len(i.metadata),
+		BytesUsed:       humanize.Bytes(i.bytesInUse),
+		BytesConfigured: i.conf.InMemoryCacheSize.String(),
+	}
+}
+
 // newInMemoryCacheClient initializes an [inMemoryCache] client.
-func newInMemoryCacheClient(conf InMemoryConfig) inMemoryCache {
-	avgAssetSize, _ := humanize.ParseBytes("350 kB")
-	cacheSize := 1
-	if configuredSize := uint64(conf.InMemoryCacheSize) / avgAssetSize; configuredSize > 0 {
-		cacheSize = int(configuredSize)
+func newInMemoryCacheClient(conf InMemoryConfig) *inMemoryCache {
+	// The cache is bounded by bytes in StoreAsset rather than by the
+	// number of entries.
+	assets, _ := lru.New[string, *Asset](math.MaxInt)
+	return &inMemoryCache{
+		conf:     conf,
+		assets:   assets,
+		metadata: make(map[string]any),
 	}
-	l, _ := lru.New[string, any](cacheSize)
-	return inMemoryCache{l, conf}
 }
@@ -0,0 +1,125 @@
+package immich
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestInMemoryCacheEvictsByBytes(t *testing.T) {
+	c := newInMemoryCacheClient(InMemoryConfig{UseInMemoryCache: true, InMemoryCacheSize: 1000})
+	for _, id := range []AssetID{"asset-1", "asset-2", "asset-3"} {
+		if err := c.StoreAsset(testAsset(id, 300)); err != nil {
+			t.Fatalf("failed to store %q: %v", id, err)
+		}
+	}
+	// Access asset-1 so asset-2 becomes the least recently used.
+	if _, err := c.GetAsset(AssetMetadata{ID: "asset-1"}, AssetQualityPreview); err != nil {
+		t.Fatalf("failed to get asset-1: %v", err)
+	}
+	if err := c.StoreAsset(testAsset("asset-4", 300)); err != nil {
+		t.Fatalf("failed to store asset-4: %v", err)
+	}
+
+	want := map[AssetID]bool{
+		"asset-1": true,
+		"asset-2": false,
+		"asset-3": true,
+		"asset-4": true,
+	}
+	for id, cached := range want {
+		_, err := c.GetAsset(AssetMetadata{ID: id}, AssetQualityPreview)
+		if cached && err != nil {
+			t.Errorf("expected %q to be cached, got error: %v", id, err)
+		} else if !cached && err == nil {
+			t.Errorf("expected %q to be evicted", id)
+		}
+	}
+	if c.bytesInUse != 900 {
+		t.Fatalf("expected 900 bytes in use, got %d", c.bytesInUse)
+	}
+}
+
+func TestInMemoryCacheReplace(t *testing.T) {
+	c := newInMemoryCacheClient(InMemoryConfig{UseInMemoryCache: true, InMemoryCacheSize: 1000})
+	if err := c.StoreAsset(testAsset("asset-1", 300)); err != nil {
+		t.Fatalf("failed to store asset-1: %v", err)
+	}
+	if err := c.StoreAsset(testAsset("asset-1", 500)); err != nil {
+		t.Fatalf("failed to replace asset-1: %v", err)
+	}
+	if c.bytesInUse != 500 {
+		t.Fatalf("expected 500 bytes in use, got %d", c.bytesInUse)
+	}
+}
+
+func TestInMemoryCacheTooLarge(t *testing.T) {
+	c := newInMemoryCacheClient(InMemoryConfig{UseInMemoryCache: true, InMemoryCacheSize: 100})
+	if err := c.StoreAsset(testAsset("asset-1", 101)); err == nil {
+		t.Fatal("expected an error storing an asset larger than the cache")
+	}
+}
+
+func TestInMemoryCacheKeepsMetadata(t *testing.T) {
+	c := newInMemoryCacheClient(InMemoryConfig{UseInMemoryCache: true, InMemoryCacheSize: 1000})
+	if err := c.StoreAlbums(GetAlbumsResponse{Albums: []Album{{ID: "album-1"}}}); err != nil {
+		t.Fatalf("failed to store albums: %v", err)
+	}
+	if err := c.StoreAlbumAssets("album-1", GetAlbumAssetsResponse{
+		AssetMetadatas: []AssetMetadata{{ID: "asset-1"}},
+	}); err != nil {
+		t.Fatalf("failed to store album assets: %v", err)
+	}
+	for _, id := range []AssetID{"asset-1", "asset-2", "asset-3", "asset-4"} {
+		if err := c.StoreAsset(testAsset(id, 500)); err != nil {
+			t.Fatalf("failed to store %q: %v", id, err)
+		}
+	}
+
+	if _, err := c.GetAlbums(); err != nil {
+		t.Errorf("expected albums to be kept, got error: %v", err)
+	}
+	resp, err := c.GetAlbumAssets("album-1")
+	if err != nil {
+		t.Fatalf("expected album assets to be kept, got error: %v", err)
+	}
+	if len(resp.AssetMetadatas) != 1 {
+		t.Fatalf("expected 1 asset metadata, got %d", len(resp.AssetMetadatas))
+	}
+}
+
+func TestInMemoryCacheAlbumAssetsSurviveEviction(t *testing.T) {
+	c := newInMemoryCacheClient(InMemoryConfig{UseInMemoryCache: true, InMemoryCacheSize: 1000})
+	albums := map[AlbumID][]AssetMetadata{
+		"album-1": {{ID: "asset-1"}, {ID: "asset-2"}},
+		"album-2": {{ID: "asset-3"}},
+	}
+	for id, mds := range albums {
+		if err := c.StoreAlbumAssets(id, GetAlbumAssetsResponse{AssetMetadatas: mds}); err != nil {
+			t.Fatalf("failed to store %q: %v", id, err)
+		}
+	}
+	// Each asset fills the cache, so every store evicts the previous one.
+	for i := range 10 {
+		id := AssetID(fmt.Sprintf("image-%d", i))
+		if err := c.StoreAsset(testAsset(id, 1000)); err != nil {
+			t.Fatalf("failed to store %q: %v", id, err)
+		}
+	}
+
+	if _, err := c.GetAsset(AssetMetadata{ID: "image-8"}, AssetQualityPreview); err == nil {
+		t.Error("expected image-8 to be evicted")
+	}
+	for id, want := range albums {
+		resp, err := c.GetAlbumAssets(id)
+		if err != nil {
+			t.Fatalf("expected %q to be kept, got error: %v", id, err)
+		}
+		if !slices.EqualFunc(resp.AssetMetadatas, want, func(a, b AssetMetadata) bool { return a.ID == b.ID }) {
+			t.Errorf("expected %q to have %+v, got %+v", id, want, resp.AssetMetadatas)
+		}
+	}
+	if c.bytesInUse != 1000 {
+		t.Fatalf("expected 1000 bytes in use, got %d", c.bytesInUse)
+	}
+}
@@ -5,9 +5,30 @@ import "immich-photo-frame/internal/immich/api"
 // Redeclare the immich API types.
 type Asset = api.Asset
 type AssetID = api.AssetID
+type AssetQuality = api.AssetQuality
 type Album = api.Album
 type AlbumID = api.AlbumID
 type AssetMetadata = api.AssetMetadata
 type ExifInfo = api.ExifInfo
+type Person = api.Person
+type PersonID = api.PersonID
+type Face = api.Face
 type GetAlbumsResponse = api.GetAlbumsResponse
 type GetAlbumAssetsResponse = api.GetAlbumsAssetsResponse
+type Memory = api.Memory
+type GetMemoriesResponse = api.GetMemoriesResponse
+
+// Redeclare the immich API asset qualities.
+const (
+	AssetQualityThumbnail = api.AssetQualityThumbnail
+	AssetQualityPreview   = api.AssetQualityPreview
+	AssetQualityFullsize  = api.AssetQualityFullsize
+	AssetQualityOriginal  = api.AssetQualityOriginal
+	AssetQualityAuto      = api.AssetQualityAuto
+)
+
+// SmallestAssetQuality returns the smallest immich rendition whose shortest
+// side is at least size pixels long. See [api.SmallestAssetQuality].
+func SmallestAssetQuality(size int) AssetQuality {
+	return api.SmallestAssetQuality(size)
+}